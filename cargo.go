@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// cargoSubcommands are the standard cargo subcommands offered for every crate
+var cargoSubcommands = []struct {
+	name        string
+	description string
+}{
+	{"build", "Compile the current package"},
+	{"test", "Run the tests"},
+	{"run", "Run a binary or example of the local package"},
+	{"check", "Analyze the current package and report errors"},
+	{"clippy", "Check the package for common mistakes"},
+	{"fmt", "Format the package's source code"},
+}
+
+// CargoScriptSource handles cargo subcommands and aliases for Rust projects
+type CargoScriptSource struct {
+	PackageName    string
+	PackageVersion string
+}
+
+func (c *CargoScriptSource) Name() string {
+	if c.PackageName == "" {
+		return "Cargo.toml"
+	}
+	return fmt.Sprintf("%s@%s", c.PackageName, c.PackageVersion)
+}
+
+func (c *CargoScriptSource) GetScripts() ([]list.Item, error) {
+	// Read Cargo.toml for the package name and version
+	var cargoTOML struct {
+		Package struct {
+			Name    string `toml:"name"`
+			Version string `toml:"version"`
+		} `toml:"package"`
+	}
+	if _, err := toml.DecodeFile("Cargo.toml", &cargoTOML); err != nil {
+		return nil, fmt.Errorf("error parsing Cargo.toml: %w", err)
+	}
+	c.PackageName = cargoTOML.Package.Name
+	c.PackageVersion = cargoTOML.Package.Version
+
+	// Create items for the standard subcommands
+	items := []list.Item{}
+	for _, sub := range cargoSubcommands {
+		items = append(items, item{name: sub.name, description: sub.description, command: "cargo " + sub.name, source: "cargo"})
+	}
+
+	// Add aliases from .cargo/config.toml, showing their expansion
+	aliases, err := cargoAliases()
+	if err != nil {
+		return nil, err
+	}
+	for _, alias := range aliases {
+		items = append(items, item{name: alias.name, description: "alias for cargo " + alias.expansion, command: "cargo " + alias.expansion, source: "cargo"})
+	}
+
+	return items, nil
+}
+
+// CommandArgs returns the cargo command line running name, without
+// looking for cargo
+func (c *CargoScriptSource) CommandArgs(name string, extraArgs []string) ([]string, error) {
+	// Prepare arguments for cargo, extra args are appended directly
+	args := []string{"cargo", name}
+	args = append(args, extraArgs...)
+
+	return args, nil
+}
+
+func (c *CargoScriptSource) ResolveCommand(name string, extraArgs []string) (string, []string, error) {
+	// Find the path to cargo executable
+	cargoPath, err := exec.LookPath("cargo")
+	if err != nil {
+		return "", nil, fmt.Errorf("cargo not found: %w", err)
+	}
+
+	args, err := c.CommandArgs(name, extraArgs)
+	if err != nil {
+		return "", nil, err
+	}
+	return cargoPath, args, nil
+}
+
+func (c *CargoScriptSource) RunScript(name string, extraArgs, env []string) error {
+	path, args, err := c.ResolveCommand(name, extraArgs)
+	if err != nil {
+		return err
+	}
+
+	// Replace the current process with the command
+	return runExec(path, args, env)
+}
+
+// cargoAlias is an entry of the [alias] section of a cargo config
+type cargoAlias struct {
+	name      string
+	expansion string
+}
+
+// cargoAliases reads the [alias] section of .cargo/config.toml (or the
+// legacy .cargo/config). Alias values are a string or an array of strings.
+func cargoAliases() ([]cargoAlias, error) {
+	for _, name := range []string{"config.toml", "config"} {
+		path := filepath.Join(".cargo", name)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+
+		var config struct {
+			Alias map[string]interface{} `toml:"alias"`
+		}
+		meta, err := toml.DecodeFile(path, &config)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", path, err)
+		}
+
+		// Keep the order the aliases are declared in
+		aliases := []cargoAlias{}
+		for _, key := range meta.Keys() {
+			if len(key) != 2 || key[0] != "alias" {
+				continue
+			}
+			switch value := config.Alias[key[1]].(type) {
+			case string:
+				aliases = append(aliases, cargoAlias{name: key[1], expansion: value})
+			case []interface{}:
+				parts := []string{}
+				for _, part := range value {
+					parts = append(parts, fmt.Sprint(part))
+				}
+				aliases = append(aliases, cargoAlias{name: key[1], expansion: strings.Join(parts, " ")})
+			}
+		}
+		return aliases, nil
+	}
+
+	return nil, nil
+}
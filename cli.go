@@ -0,0 +1,332 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// options holds the parsed command line flags
+type options struct {
+	help            bool
+	dir             string // -C, the directory to run in
+	version         bool
+	clearHistory    bool
+	list            bool
+	json            bool
+	npmDefaults     bool
+	dryRun          bool
+	printResult     bool // print the chosen scripts as JSON instead of running them
+	noCache         bool
+	noSearchUp      bool // only look for scripts in the working directory
+	noEnv           bool // don't load .env files
+	envOverride     bool // .env files override rx's environment
+	showHooks       bool
+	includeInternal bool // list internal make targets too
+	parallel        bool
+	watch           bool
+	watchGlobs      []string // limit --watch to files matching these globs
+	source          string
+	sort            string
+	theme           string
+	noColor         bool
+	noMouse         bool
+	selectFirst     bool     // start in the list instead of the filter
+	autoRun         bool     // run the only script the filter matches
+	tmux            string   // run the script in a new tmux "split" or "window"
+	firstMatch      bool     // rx run with names that fuzzy match a script
+	shell           bool     // run scripts through the login shell
+	useNVM          bool     // run npm scripts after nvm use
+	pmFallback      bool     // run npm scripts with npm when yarn or pnpm is missing
+	recentDirs      bool     // pick a recently used directory to run in first
+	confirmAll      bool     // ask with a summary before running any script
+	timeout         string   // limit for commands listing scripts, like 30s
+	extraArgs       []string // arguments after "--", passed to the script
+
+	// --profile times the phases of the run, nil without it
+	profile *profiler
+
+	// Flags of init
+	installPath   string // install to this directory instead of ~/.local/bin
+	quiet         bool   // only print errors
+	noShellConfig bool   // don't add the install directory to PATH
+}
+
+// parseArgs parses the command line into options and positional arguments.
+// Flags may appear before or after the command, and everything after "--"
+// is passed through to the script untouched.
+func parseArgs(args []string) (options, []string, error) {
+	var opts options
+
+	for i, arg := range args {
+		if arg == "--" {
+			opts.extraArgs = args[i+1:]
+			args = args[:i]
+			break
+		}
+	}
+
+	fs := flag.NewFlagSet("rx", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&opts.help, "h", false, "")
+	fs.BoolVar(&opts.help, "help", false, "")
+	fs.StringVar(&opts.dir, "C", "", "")
+	fs.StringVar(&opts.dir, "cwd", "", "")
+	fs.BoolVar(&opts.version, "v", false, "")
+	fs.BoolVar(&opts.version, "version", false, "")
+	fs.BoolVar(&opts.clearHistory, "clear-history", false, "")
+	fs.BoolVar(&opts.list, "l", false, "")
+	fs.BoolVar(&opts.list, "list", false, "")
+	fs.BoolVar(&opts.json, "json", false, "")
+	fs.BoolVar(&opts.npmDefaults, "npm-defaults", false, "")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "")
+	fs.BoolVar(&opts.printResult, "print-result", false, "")
+	fs.BoolVar(&opts.noCache, "no-cache", false, "")
+	fs.BoolVar(&opts.noSearchUp, "no-search-up", false, "")
+	fs.BoolVar(&opts.noEnv, "no-env", false, "")
+	fs.BoolVar(&opts.envOverride, "env-override", false, "")
+	fs.BoolVar(&opts.showHooks, "show-hooks", false, "")
+	fs.BoolVar(&opts.includeInternal, "include-internal", false, "")
+	fs.BoolVar(&opts.parallel, "parallel", false, "")
+	fs.BoolVar(&opts.watch, "watch", false, "")
+	fs.Var((*stringList)(&opts.watchGlobs), "watch-glob", "")
+	fs.StringVar(&opts.source, "source", "", "")
+	fs.StringVar(&opts.sort, "sort", "", "")
+	fs.StringVar(&opts.theme, "theme", "", "")
+	fs.BoolVar(&opts.noColor, "no-color", false, "")
+	fs.BoolVar(&opts.noMouse, "no-mouse", false, "")
+	fs.BoolVar(&opts.selectFirst, "select-first", false, "")
+	fs.BoolVar(&opts.autoRun, "auto-run", false, "")
+	fs.StringVar(&opts.tmux, "tmux", "", "")
+	fs.BoolVar(&opts.firstMatch, "1", false, "")
+	fs.BoolVar(&opts.firstMatch, "first-match", false, "")
+	fs.BoolVar(&opts.shell, "shell", false, "")
+	fs.BoolVar(&opts.useNVM, "use-nvm", false, "")
+	fs.BoolVar(&opts.pmFallback, "pm-fallback", false, "")
+	fs.BoolVar(&opts.recentDirs, "recent-dirs", false, "")
+	fs.BoolVar(&opts.confirmAll, "confirm-all", false, "")
+	profile := fs.Bool("profile", false, "")
+	fs.StringVar(&opts.timeout, "timeout", "", "")
+	fs.StringVar(&opts.installPath, "path", "", "")
+	fs.BoolVar(&opts.quiet, "quiet", false, "")
+	fs.BoolVar(&opts.noShellConfig, "no-shell-config", false, "")
+
+	// The flag package stops at the first positional argument, so keep
+	// parsing after each one to allow flags anywhere on the line
+	positional := []string{}
+	for {
+		if err := fs.Parse(args); err != nil {
+			return opts, nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	if opts.sort != "" && !contains(sortOrders, opts.sort) {
+		return opts, nil, fmt.Errorf("invalid --sort %q, must be one of %s", opts.sort, strings.Join(sortOrders, ", "))
+	}
+
+	if opts.theme != "" && !contains(themeNames, opts.theme) {
+		return opts, nil, fmt.Errorf("invalid --theme %q, must be one of %s", opts.theme, strings.Join(themeNames, ", "))
+	}
+
+	if opts.tmux != "" && !contains(tmuxModes, opts.tmux) {
+		return opts, nil, fmt.Errorf("invalid --tmux %q, must be one of %s", opts.tmux, strings.Join(tmuxModes, ", "))
+	}
+
+	if opts.timeout != "" {
+		if _, err := parseTimeout(opts.timeout); err != nil {
+			return opts, nil, fmt.Errorf("invalid --timeout %q: %w", opts.timeout, err)
+		}
+	}
+
+	if opts.source != "" && !contains(sourceTags(), opts.source) {
+		return opts, nil, fmt.Errorf("invalid --source %q, must be one of %s", opts.source, strings.Join(sourceTags(), ", "))
+	}
+
+	// --watch-glob only makes sense while watching
+	if len(opts.watchGlobs) > 0 {
+		opts.watch = true
+	}
+	for _, glob := range opts.watchGlobs {
+		if _, err := path.Match(glob, ""); err != nil {
+			return opts, nil, fmt.Errorf("invalid --watch-glob %q: %w", glob, err)
+		}
+	}
+
+	if *profile {
+		opts.profile = newProfiler()
+	}
+
+	// --json on its own lists scripts as JSON
+	if opts.json {
+		opts.list = true
+	}
+
+	return opts, positional, nil
+}
+
+// changeDir makes the -C directory the working directory, so sources find
+// their files there and scripts run there
+func (o options) changeDir() error {
+	if o.dir == "" {
+		return nil
+	}
+	info, err := os.Stat(o.dir)
+	if err != nil {
+		return fmt.Errorf("-C %s: %w", o.dir, errors.Unwrap(err))
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("-C %s: not a directory", o.dir)
+	}
+	if err := os.Chdir(o.dir); err != nil {
+		return fmt.Errorf("-C %s: %w", o.dir, errors.Unwrap(err))
+	}
+	return nil
+}
+
+// findRoot moves to the project root above the working directory unless
+// --no-search-up is given, telling the user on stderr when it does
+func (o options) findRoot(cfg Config) (string, error) {
+	if o.noSearchUp {
+		return "", nil
+	}
+	start, _ := os.Getwd()
+	root, err := findProjectRoot(o.sourceOptions(cfg))
+	if err != nil || root == "" {
+		return "", err
+	}
+	if rel, err := filepath.Rel(start, root); err == nil {
+		fmt.Fprintf(os.Stderr, "rx: using scripts in %s (%s)\n", root, rel)
+	} else {
+		fmt.Fprintf(os.Stderr, "rx: using scripts in %s\n", root)
+	}
+	return root, nil
+}
+
+// dotenv returns the variables of the project's .env files for scripts,
+// unless --no-env is given. A file that can't be read is skipped with a
+// warning.
+func (o options) dotenv() []string {
+	env, err := o.readDotenv()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "rx: warning: ignoring .env files: "+err.Error())
+		return nil
+	}
+	return env
+}
+
+// readDotenv reads the .env files of the working directory, unless
+// --no-env is given
+func (o options) readDotenv() ([]string, error) {
+	if o.noEnv {
+		return nil, nil
+	}
+	return loadDotenv(o.envOverride)
+}
+
+// stringList is a flag that can be repeated, collecting every value
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// sourceOptions combines the flags and config that affect script sources.
+// Flags take precedence over the config file.
+func (o options) sourceOptions(cfg Config) sourceOptions {
+	return sourceOptions{
+		PackageManager:  cfg.PackageManager,
+		NPMDefaults:     o.npmDefaults,
+		SourceOrder:     o.sortOrder(cfg) == "source",
+		NoCache:         o.noCache,
+		Timeout:         o.listTimeout(cfg),
+		ShowHooks:       o.showHooks,
+		IncludeInternal: o.includeInternal,
+		Shell:           o.shell,
+		UseNVM:          o.useNVM,
+		PMFallback:      o.pmFallback,
+		Source:          o.source,
+		Profile:         o.profile,
+		ScriptsDir:      cfg.ScriptsDir,
+		Warn: func(err error) {
+			fmt.Fprintln(os.Stderr, "rx: warning: "+err.Error())
+		},
+	}
+}
+
+// sortOrder returns the list order from --sort or the config file
+func (o options) sortOrder(cfg Config) string {
+	if o.sort != "" {
+		return o.sort
+	}
+	return cfg.Sort
+}
+
+// listTimeout returns the limit for commands listing scripts from
+// --timeout or the config file, defaultListTimeout when neither sets one
+func (o options) listTimeout(cfg Config) time.Duration {
+	for _, value := range []string{o.timeout, cfg.Timeout} {
+		if timeout, err := parseTimeout(value); value != "" && err == nil {
+			return timeout
+		}
+	}
+	return defaultListTimeout
+}
+
+// parseTimeout parses a duration like 30s or 1m, 0 for no limit
+func parseTimeout(value string) (time.Duration, error) {
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if timeout < 0 {
+		return 0, fmt.Errorf("must not be negative")
+	}
+	return timeout, nil
+}
+
+// tmuxMode returns how to run the script in tmux from --tmux or the config
+// file, or "" to run it in place, as outside tmux
+func (o options) tmuxMode(cfg Config) string {
+	if !inTmux() {
+		return ""
+	}
+	if o.tmux != "" {
+		return o.tmux
+	}
+	return cfg.Tmux
+}
+
+// mouseEnabled reports whether the picker handles the mouse, unless
+// --no-mouse or the config file turn it off
+func (o options) mouseEnabled(cfg Config) bool {
+	if o.noMouse {
+		return false
+	}
+	return cfg.Mouse == nil || *cfg.Mouse
+}
+
+// themeName returns the theme from --theme or the config file, or the one
+// suiting the terminal's background
+func (o options) themeName(cfg Config) string {
+	if o.theme != "" {
+		return o.theme
+	}
+	if cfg.Theme != "" {
+		return cfg.Theme
+	}
+	return defaultTheme()
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// completionShells are the shells rx completion writes a script for
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// completionCommands are the subcommands offered as the first argument
+var completionCommands = []string{"run", "stats", "init", "uninstall", "completion", "help", "version"}
+
+// completionFlag is a flag offered by the completion scripts. The values
+// of flags that take one are completed by each script itself.
+type completionFlag struct {
+	short       string
+	long        string
+	description string
+	value       bool
+}
+
+var completionFlags = []completionFlag{
+	{short: "h", long: "help", description: "Show help"},
+	{short: "v", long: "version", description: "Show the rx version"},
+	{short: "C", long: "cwd", description: "Find and run scripts in a directory", value: true},
+	{long: "recent-dirs", description: "Pick a recently used directory first"},
+	{short: "l", long: "list", description: "Print scripts"},
+	{short: "1", long: "first-match", description: "Run the script a query fuzzy matches"},
+	{long: "json", description: "Print scripts as JSON"},
+	{long: "clear-history", description: "Forget recently run scripts"},
+	{long: "dry-run", description: "Print the command instead of running it"},
+	{long: "confirm-all", description: "Ask with a summary before running a script"},
+	{long: "print-result", description: "Print the chosen script as JSON"},
+	{long: "parallel", description: "Run queued scripts at the same time"},
+	{long: "watch", description: "Run the script again when files change"},
+	{long: "watch-glob", description: "Only restart for files matching a glob", value: true},
+	{long: "sort", description: "Order scripts", value: true},
+	{long: "theme", description: "Color scheme", value: true},
+	{long: "no-color", description: "Print plain text"},
+	{long: "no-mouse", description: "Leave the mouse to the terminal"},
+	{long: "select-first", description: "Start in the list instead of the filter"},
+	{long: "auto-run", description: "Run the only script the filter matches"},
+	{long: "tmux", description: "Run the script in a new tmux pane or window", value: true},
+	{long: "shell", description: "Run scripts through the login shell"},
+	{long: "use-nvm", description: "Switch node with nvm use before npm scripts"},
+	{long: "pm-fallback", description: "Run npm scripts with npm when yarn or pnpm is missing"},
+	{long: "source", description: "Use only this source", value: true},
+	{long: "no-env", description: "Don't load .env files"},
+	{long: "env-override", description: "Let .env files override the environment"},
+	{long: "no-search-up", description: "Only look for scripts in the current directory"},
+	{long: "timeout", description: "Limit for commands listing scripts", value: true},
+	{long: "no-cache", description: "Run make -pn instead of using the cache"},
+	{long: "profile", description: "Print how long each phase took"},
+	{long: "show-hooks", description: "List npm pre/post hooks"},
+	{long: "include-internal", description: "List internal make targets too"},
+	{long: "npm-defaults", description: "Offer install, test and start"},
+	{long: "path", description: "Install directory for init", value: true},
+	{long: "quiet", description: "Only print errors from init"},
+	{long: "no-shell-config", description: "Don't add rx to PATH in init"},
+}
+
+// completionWords lists every flag as it's typed, for bash and zsh
+func completionWords() string {
+	words := []string{}
+	for _, flag := range completionFlags {
+		if flag.short != "" {
+			words = append(words, "-"+flag.short)
+		}
+		words = append(words, "--"+flag.long)
+	}
+	return strings.Join(words, " ")
+}
+
+// fishFlags declares the flags without a value for fish
+func fishFlags() string {
+	lines := []string{}
+	for _, flag := range completionFlags {
+		if flag.value {
+			continue
+		}
+		line := "complete -c rx"
+		if flag.short != "" {
+			line += " -s " + flag.short
+		}
+		line += fmt.Sprintf(" -l %s -d '%s'", flag.long, strings.ReplaceAll(flag.description, "'", `\'`))
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writeCompletion writes the completion script for shell to w. Script
+// names are completed by calling rx --list in the current directory.
+func writeCompletion(w io.Writer, shell string) error {
+	var script string
+	switch shell {
+	case "bash":
+		script = bashCompletion
+	case "zsh":
+		script = zshCompletion
+	case "fish":
+		script = fishCompletion
+	default:
+		return fmt.Errorf("unsupported shell %q, must be one of %s", shell, strings.Join(completionShells, ", "))
+	}
+
+	replacer := strings.NewReplacer(
+		"{{commands}}", strings.Join(completionCommands, " "),
+		"{{flags}}", completionWords(),
+		"{{fish_flags}}", fishFlags(),
+		"{{sources}}", strings.Join(sourceTags(), " "),
+		"{{sorts}}", strings.Join(sortOrders, " "),
+		"{{themes}}", strings.Join(themeNames, " "),
+		"{{tmux}}", strings.Join(tmuxModes, " "),
+		"{{shells}}", strings.Join(completionShells, " "),
+	)
+	_, err := io.WriteString(w, replacer.Replace(script))
+	return err
+}
+
+const bashCompletion = `# bash completion for rx, load with: source <(rx completion bash)
+_rx() {
+    local cur prev
+    if declare -F _get_comp_words_by_ref >/dev/null; then
+        # npm script names like test:unit contain colons
+        _get_comp_words_by_ref -n : cur prev
+    else
+        cur="${COMP_WORDS[COMP_CWORD]}"
+        prev="${COMP_WORDS[COMP_CWORD-1]}"
+    fi
+
+    case "$prev" in
+        -C|--cwd|--path) COMPREPLY=($(compgen -d -- "$cur")); return ;;
+        --source) COMPREPLY=($(compgen -W "{{sources}}" -- "$cur")); return ;;
+        --sort) COMPREPLY=($(compgen -W "{{sorts}}" -- "$cur")); return ;;
+        --theme) COMPREPLY=($(compgen -W "{{themes}}" -- "$cur")); return ;;
+        --tmux) COMPREPLY=($(compgen -W "{{tmux}}" -- "$cur")); return ;;
+        --watch-glob|--timeout) return ;;
+    esac
+
+    local i cmd="" dir=.
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case "${COMP_WORDS[i]}" in
+            --) return ;;
+            -C|--cwd) ((i++)); dir="${COMP_WORDS[i]}" ;;
+            --source|--sort|--theme|--tmux|--watch-glob|--timeout|--path) ((i++)) ;;
+            -*) ;;
+            *) cmd="${COMP_WORDS[i]}"; break ;;
+        esac
+    done
+
+    if [[ "$cur" == -* ]]; then
+        COMPREPLY=($(compgen -W "{{flags}}" -- "$cur"))
+        return
+    fi
+
+    case "$cmd" in
+        "") COMPREPLY=($(compgen -W "{{commands}}" -- "$cur")) ;;
+        run)
+            local IFS=$'\n'
+            COMPREPLY=($(compgen -W "$(rx -C "$dir" --list 2>/dev/null | cut -f1)" -- "$cur"))
+            if declare -F __ltrim_colon_completions >/dev/null; then
+                __ltrim_colon_completions "$cur"
+            fi
+            ;;
+        completion) COMPREPLY=($(compgen -W "{{shells}}" -- "$cur")) ;;
+    esac
+}
+complete -F _rx rx
+`
+
+const zshCompletion = `#compdef rx
+# zsh completion for rx, load with: source <(rx completion zsh)
+# or save it as _rx in a directory on your $fpath
+_rx() {
+    local i cmd dir=.
+    local -a scripts
+    for ((i = 2; i < CURRENT; i++)); do
+        case "${words[i]}" in
+            --) return ;;
+            -C|--cwd) ((i++)); dir="${(Q)words[i]}" ;;
+            --source|--sort|--theme|--tmux|--watch-glob|--timeout|--path) ((i++)) ;;
+            -*) ;;
+            *) cmd="${words[i]}"; break ;;
+        esac
+    done
+
+    case "${words[CURRENT-1]}" in
+        -C|--cwd|--path) _directories; return ;;
+        --source) compadd -- {{sources}}; return ;;
+        --sort) compadd -- {{sorts}}; return ;;
+        --theme) compadd -- {{themes}}; return ;;
+        --tmux) compadd -- {{tmux}}; return ;;
+        --watch-glob) _files; return ;;
+        --timeout) return ;;
+    esac
+
+    if [[ "$PREFIX" == -* ]]; then
+        compadd -- {{flags}}
+        return
+    fi
+
+    case "$cmd" in
+        "") compadd -- {{commands}} ;;
+        run)
+            scripts=(${(f)"$(rx -C "$dir" --list 2>/dev/null | awk -F'\t' '{ gsub(/:/, "\\:", $1); print $1 ":" $2 }')"})
+            _describe 'script' scripts
+            ;;
+        completion) compadd -- {{shells}} ;;
+    esac
+}
+
+if [[ "$funcstack[1]" == "_rx" ]]; then
+    _rx "$@"
+else
+    compdef _rx rx
+fi
+`
+
+const fishCompletion = `# fish completion for rx, load with: rx completion fish | source
+# or save it as ~/.config/fish/completions/rx.fish
+function __rx_scripts
+    set -l dir .
+    set -l args (commandline -opc)
+    for i in (seq (count $args))
+        if contains -- $args[$i] -C --cwd; and test $i -lt (count $args)
+            set dir $args[(math $i + 1)]
+        end
+    end
+    rx -C $dir --list 2>/dev/null | cut -f1,2
+end
+
+set -l commands {{commands}}
+complete -c rx -f
+complete -c rx -n "not __fish_seen_subcommand_from $commands" -a "$commands"
+complete -c rx -n "__fish_seen_subcommand_from run" -a "(__rx_scripts)"
+complete -c rx -n "__fish_seen_subcommand_from completion" -a "{{shells}}"
+complete -c rx -s C -l cwd -x -a "(__fish_complete_directories)" -d 'Find and run scripts in a directory'
+complete -c rx -l source -x -a "{{sources}}" -d 'Use only this source'
+complete -c rx -l sort -x -a "{{sorts}}" -d 'Order scripts'
+complete -c rx -l theme -x -a "{{themes}}" -d 'Color scheme'
+complete -c rx -l tmux -x -a "{{tmux}}" -d 'Run the script in a new tmux pane or window'
+complete -c rx -l watch-glob -x -d 'Only restart for files matching a glob'
+complete -c rx -l timeout -x -d 'Limit for commands listing scripts'
+complete -c rx -l path -x -a "(__fish_complete_directories)" -d 'Install directory for init'
+{{fish_flags}}
+`
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"gopkg.in/yaml.v3"
+)
+
+// composeFileNames are the file names docker compose looks for, in order
+var composeFileNames = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// composeActions are the actions offered for every service
+var composeActions = []struct {
+	name        string
+	description string
+}{
+	{"up", "Start the service and its dependencies"},
+	{"run", "Run a one-off container of the service"},
+	{"logs", "Show the service's logs"},
+	{"restart", "Restart the service"},
+}
+
+// ComposeScriptSource handles actions on the services of a Docker Compose
+// file. Items are named "<action> <service>", e.g. "logs web".
+type ComposeScriptSource struct{}
+
+func (c *ComposeScriptSource) Name() string {
+	return findComposeFile()
+}
+
+func (c *ComposeScriptSource) GetScripts() ([]list.Item, error) {
+	// Check if a compose file exists
+	path := findComposeFile()
+	if path == "" {
+		return nil, fmt.Errorf("compose file not found")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	services, err := parseComposeServices(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	if len(services) == 0 {
+		return nil, fmt.Errorf("no services found in %s", path)
+	}
+
+	// Create an item for each action on each service
+	items := []list.Item{}
+	for _, service := range services {
+		for _, action := range composeActions {
+			name := action.name + " " + service
+			items = append(items, item{name: name, description: action.description, command: "docker compose " + name, source: "compose"})
+		}
+	}
+
+	return items, nil
+}
+
+// CommandArgs returns the docker compose command line running name,
+// without looking for docker
+func (c *ComposeScriptSource) CommandArgs(name string, extraArgs []string) ([]string, error) {
+	action, service, ok := strings.Cut(name, " ")
+	if !ok {
+		return nil, fmt.Errorf("invalid compose item %q, want \"<action> <service>\"", name)
+	}
+
+	// Prepare arguments for docker compose, extra args follow the service
+	// so they become the command of docker compose run
+	args := []string{"docker", "compose", action, service}
+	args = append(args, extraArgs...)
+
+	return args, nil
+}
+
+func (c *ComposeScriptSource) ResolveCommand(name string, extraArgs []string) (string, []string, error) {
+	args, err := c.CommandArgs(name, extraArgs)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Find the path to docker executable
+	dockerPath, err := exec.LookPath("docker")
+	if err != nil {
+		return "", nil, fmt.Errorf("docker not found: %w", err)
+	}
+	return dockerPath, args, nil
+}
+
+func (c *ComposeScriptSource) RunScript(name string, extraArgs, env []string) error {
+	path, args, err := c.ResolveCommand(name, extraArgs)
+	if err != nil {
+		return err
+	}
+
+	// Replace the current process with the command
+	return runExec(path, args, env)
+}
+
+// findComposeFile returns the compose file in the current directory, if any
+func findComposeFile() string {
+	for _, name := range composeFileNames {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// parseComposeServices returns the service names of a compose file in
+// declaration order
+func parseComposeServices(data []byte) ([]string, error) {
+	var compose struct {
+		Services yaml.Node `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(data, &compose); err != nil {
+		return nil, err
+	}
+
+	services := []string{}
+	if compose.Services.Kind != yaml.MappingNode {
+		return services, nil
+	}
+
+	// Mapping nodes alternate key and value
+	for i := 0; i+1 < len(compose.Services.Content); i += 2 {
+		services = append(services, compose.Services.Content[i].Value)
+	}
+	return services, nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// ComposerScriptSource handles scripts from composer.json
+type ComposerScriptSource struct {
+	PackageName string
+}
+
+func (c *ComposerScriptSource) Name() string {
+	if c.PackageName == "" {
+		return "composer.json"
+	}
+	return c.PackageName
+}
+
+func (c *ComposerScriptSource) GetScripts() ([]list.Item, error) {
+	// Read composer.json
+	data, err := os.ReadFile("composer.json")
+	if err != nil {
+		return nil, fmt.Errorf("error reading composer.json: %w", err)
+	}
+
+	// Parse composer.json, script values are a command or a list of them
+	var composerJSON struct {
+		Name         string                     `json:"name"`
+		Scripts      map[string]json.RawMessage `json:"scripts"`
+		Descriptions map[string]string          `json:"scripts-descriptions"`
+	}
+
+	if err := json.Unmarshal(data, &composerJSON); err != nil {
+		return nil, fmt.Errorf("error parsing composer.json: %w", err)
+	}
+
+	if len(composerJSON.Scripts) == 0 {
+		return nil, fmt.Errorf("no scripts found in composer.json")
+	}
+
+	// Set package name
+	c.PackageName = composerJSON.Name
+
+	// Create items for the list
+	items := []list.Item{}
+	for name, raw := range composerJSON.Scripts {
+		commands, err := composerCommands(raw)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing composer.json script %q: %w", name, err)
+		}
+
+		description := composerJSON.Descriptions[name]
+		if description == "" && len(commands) > 0 {
+			description = commands[0]
+		}
+		items = append(items, item{name: name, description: description, command: strings.Join(commands, "\n"), source: "composer"})
+	}
+
+	// JSON objects are decoded into maps, so sort for a stable order
+	sortItemsByName(items)
+
+	return items, nil
+}
+
+// CommandArgs returns the composer command line running name, without
+// looking for composer
+func (c *ComposerScriptSource) CommandArgs(name string, extraArgs []string) ([]string, error) {
+	// Prepare arguments for composer run-script, extra args go after "--"
+	args := []string{"composer", "run-script", name}
+	if len(extraArgs) > 0 {
+		args = append(args, "--")
+		args = append(args, extraArgs...)
+	}
+
+	return args, nil
+}
+
+func (c *ComposerScriptSource) ResolveCommand(name string, extraArgs []string) (string, []string, error) {
+	// Find the path to composer executable
+	composerPath, err := exec.LookPath("composer")
+	if err != nil {
+		return "", nil, fmt.Errorf("composer not found: %w", err)
+	}
+
+	args, err := c.CommandArgs(name, extraArgs)
+	if err != nil {
+		return "", nil, err
+	}
+	return composerPath, args, nil
+}
+
+func (c *ComposerScriptSource) RunScript(name string, extraArgs, env []string) error {
+	path, args, err := c.ResolveCommand(name, extraArgs)
+	if err != nil {
+		return err
+	}
+
+	// Replace the current process with the command
+	return runExec(path, args, env)
+}
+
+// composerCommands decodes a composer script, which is either a single
+// command string or an array of commands
+func composerCommands(raw json.RawMessage) ([]string, error) {
+	var command string
+	if err := json.Unmarshal(raw, &command); err == nil {
+		return []string{command}, nil
+	}
+
+	var commands []string
+	if err := json.Unmarshal(raw, &commands); err != nil {
+		return nil, fmt.Errorf("script must be a string or an array of strings")
+	}
+	return commands, nil
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Config holds the user's defaults from ~/.config/rx/config.toml.
+// Precedence is: command line flags, then this file, then built-in defaults.
+type Config struct {
+	// PackageManager overrides lockfile detection: "npm", "yarn" or "pnpm"
+	PackageManager string `toml:"package_manager"`
+	// Sort is the initial list order: "recent" (default), "frequency",
+	// "name" or "source"
+	Sort string `toml:"sort"`
+	// Theme is one of themeNames, picked from the terminal's background
+	// when unset
+	Theme string `toml:"theme"`
+	// FilterFocused starts rx with the filter focused (default true)
+	FilterFocused *bool `toml:"filter_focused"`
+	// RememberFilter restores the filter rx was last left with in a
+	// directory, saved in ~/.config/rx/filters.json
+	RememberFilter bool `toml:"remember_filter"`
+	// Mouse enables clicking and scrolling the list (default true)
+	Mouse *bool `toml:"mouse"`
+	// Tmux runs the selected script in a new tmux "split" or "window" when
+	// rx runs inside tmux
+	Tmux string `toml:"tmux"`
+	// Log appends every script run to ~/.local/state/rx/run.log, one JSON
+	// object per line
+	Log bool `toml:"log"`
+	// DescriptionLength cuts descriptions in the list to this many columns,
+	// besides cutting them at the list's width. 0 (default) only does that.
+	DescriptionLength int `toml:"description_length"`
+	// ScriptsDir is the directory of executable scripts to list, instead
+	// of scripts/ or .rx/. Unlike those, it's listed alongside other sources.
+	ScriptsDir string `toml:"scripts_dir"`
+	// Timeout limits commands listing scripts, like make -pn, e.g. "30s".
+	// Defaults to defaultListTimeout, "0" waits forever.
+	Timeout string `toml:"timeout"`
+	// Confirm lists script name patterns that ask before running. Plain
+	// words match anywhere in the name, globs like "deploy:*" match the
+	// whole name. Defaults to defaultConfirmPatterns; set [] to disable.
+	Confirm *[]string `toml:"confirm"`
+	// ConfirmAll asks before running any script from the picker, showing
+	// its command, directory, source and environment, like --confirm-all
+	ConfirmAll bool `toml:"confirm_all"`
+	// Keys rebinds actions, e.g. [keys] quit = "x" or filter = ["/", "s"].
+	// The actions are keyActions.
+	Keys map[string]keyList `toml:"keys"`
+	// Env pre-fills the environment prompt for a script, keyed by script
+	// name, e.g. [env.dev] PORT = "3000"
+	Env map[string]map[string]string `toml:"env"`
+	// Flags are offered to pick from before running a script, keyed by
+	// script name, e.g. [flags] test = ["--watch", "--coverage"]
+	Flags map[string][]string `toml:"flags"`
+	// Colors overrides the theme's colors, e.g. title = "#FF0000"
+	Colors ColorConfig `toml:"colors"`
+}
+
+// defaultConfirmPatterns are scripts that ask before running by default
+var defaultConfirmPatterns = []string{"deploy", "publish", "clean", "reset"}
+
+// confirmPatterns returns the configured destructive script patterns
+func (c Config) confirmPatterns() []string {
+	if c.Confirm == nil {
+		return defaultConfirmPatterns
+	}
+	return *c.Confirm
+}
+
+// isDestructive reports whether a script name matches one of the patterns
+func isDestructive(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.ContainsAny(pattern, "*?[") {
+			if ok, _ := filepath.Match(pattern, name); ok {
+				return true
+			}
+		} else if strings.Contains(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// ColorConfig overrides the colors used by the lipgloss styles
+type ColorConfig struct {
+	Title         string `toml:"title"`
+	Error         string `toml:"error"`
+	Success       string `toml:"success"`
+	Preview       string `toml:"preview"`
+	Match         string `toml:"match"`
+	Source        string `toml:"source"`
+	SelectedTitle string `toml:"selected_title"`
+	SelectedDesc  string `toml:"selected_desc"`
+}
+
+// Valid values for config keys
+var (
+	packageManagers = []string{"npm", "yarn", "pnpm"}
+	sortOrders      = []string{"recent", "frequency", "name", "source"}
+)
+
+// configPath returns the path of the config file
+func configPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.toml"), nil
+}
+
+// loadConfig reads the config file. A missing file yields the defaults.
+// Unknown keys and invalid values are reported as warnings rather than
+// failing, so an old rx never refuses to start because of a newer config.
+func loadConfig() (Config, []string) {
+	var cfg Config
+	var warnings []string
+
+	path, err := configPath()
+	if err != nil {
+		return cfg, []string{err.Error()}
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return cfg, nil
+	}
+
+	meta, err := toml.DecodeFile(path, &cfg)
+	if err != nil {
+		return Config{}, []string{fmt.Sprintf("ignoring %s: %v", path, err)}
+	}
+
+	for _, key := range meta.Undecoded() {
+		warnings = append(warnings, fmt.Sprintf("%s: unknown key %q", path, key.String()))
+	}
+	if cfg.PackageManager != "" && !contains(packageManagers, cfg.PackageManager) {
+		warnings = append(warnings, fmt.Sprintf("%s: package_manager must be one of %s", path, strings.Join(packageManagers, ", ")))
+		cfg.PackageManager = ""
+	}
+	if cfg.Sort != "" && !contains(sortOrders, cfg.Sort) {
+		warnings = append(warnings, fmt.Sprintf("%s: sort must be one of %s", path, strings.Join(sortOrders, ", ")))
+		cfg.Sort = ""
+	}
+	if cfg.Tmux != "" && !contains(tmuxModes, cfg.Tmux) {
+		warnings = append(warnings, fmt.Sprintf("%s: tmux must be one of %s", path, strings.Join(tmuxModes, ", ")))
+		cfg.Tmux = ""
+	}
+	if cfg.DescriptionLength < 0 {
+		warnings = append(warnings, fmt.Sprintf("%s: description_length must be 0 or more", path))
+		cfg.DescriptionLength = 0
+	}
+	if cfg.Timeout != "" {
+		if _, err := parseTimeout(cfg.Timeout); err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: timeout: %v", path, err))
+			cfg.Timeout = ""
+		}
+	}
+	if len(cfg.Keys) > 0 {
+		var keyWarnings []string
+		cfg.Keys, keyWarnings = checkKeys(cfg.Keys)
+		for _, warning := range keyWarnings {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", path, warning))
+		}
+	}
+	if cfg.Theme != "" && !contains(themeNames, cfg.Theme) {
+		warnings = append(warnings, fmt.Sprintf("%s: theme must be one of %s", path, strings.Join(themeNames, ", ")))
+		cfg.Theme = ""
+	}
+
+	return cfg, warnings
+}
+
+// applyColors overrides the package styles and the list delegate with any
+// colors set in the config
+func applyColors(colors ColorConfig, delegate *itemDelegate) {
+	set := func(style *lipgloss.Style, color string) {
+		if color != "" {
+			*style = style.Copy().Foreground(lipgloss.Color(color))
+		}
+	}
+
+	set(&titleStyle, colors.Title)
+	set(&errorStyle, colors.Error)
+	set(&successStyle, colors.Success)
+	set(&previewStyle, colors.Preview)
+	set(&delegate.MatchStyle, colors.Match)
+	set(&delegate.SourceStyle, colors.Source)
+	set(&delegate.Styles.SelectedTitle, colors.SelectedTitle)
+	set(&delegate.Styles.SelectedDesc, colors.SelectedDesc)
+}
+
+// contains reports whether values includes s
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+)
+
+const ellipsis = "…"
+
+// header is a row that starts the group of items from one source. It can't
+// be selected, see model.skipHeader.
+type header struct {
+	source string
+}
+
+func (h header) FilterValue() string { return "" }
+
+// itemDelegate renders items like list.DefaultDelegate, but highlights the
+// runes matched by our own filter since the list's built-in filtering
+// (and its match tracking) isn't used. It also renders the source headers.
+type itemDelegate struct {
+	list.DefaultDelegate
+	MatchStyle  lipgloss.Style
+	SourceStyle lipgloss.Style
+
+	// MaxDescription cuts descriptions shorter than the list's width, 0
+	// only cuts them at the width
+	MaxDescription int
+}
+
+// newItemDelegate creates the delegate with rx's styling in the colors of t
+func newItemDelegate(t theme) itemDelegate {
+	delegate := list.NewDefaultDelegate()
+	s := &delegate.Styles
+	s.NormalTitle = s.NormalTitle.Foreground(t.NormalTitle)
+	s.NormalDesc = s.NormalDesc.Foreground(t.NormalDesc)
+	s.DimmedTitle = s.DimmedTitle.Foreground(t.NormalDesc)
+	s.DimmedDesc = s.DimmedDesc.Foreground(t.NormalDesc)
+	s.SelectedTitle = s.SelectedTitle.Foreground(t.SelectedTitle).BorderForeground(t.SelectedTitle).Bold(true)
+	s.SelectedDesc = s.SelectedDesc.Foreground(t.SelectedDesc).BorderForeground(t.SelectedTitle)
+
+	return itemDelegate{
+		DefaultDelegate: delegate,
+		MatchStyle:      lipgloss.NewStyle().Foreground(t.Match).Bold(true).Underline(t.Underline),
+		SourceStyle:     lipgloss.NewStyle().Foreground(t.Source),
+	}
+}
+
+func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	if h, ok := listItem.(header); ok {
+		d.renderHeader(w, h)
+		return
+	}
+	if g, ok := listItem.(groupHeader); ok {
+		d.renderGroupHeader(w, g, index == m.Index())
+		return
+	}
+
+	i, ok := listItem.(item)
+	if !ok || m.Width() <= 0 {
+		d.DefaultDelegate.Render(w, m, index, listItem)
+		return
+	}
+
+	s := &d.Styles
+	title := i.Title()
+	desc := i.Description()
+
+	// Prevent text from exceeding list width
+	textwidth := uint(m.Width() - s.NormalTitle.GetPaddingLeft() - s.NormalTitle.GetPaddingRight())
+	marker := ""
+	if i.queued > 0 {
+		marker = fmt.Sprintf("%d▸ ", i.queued)
+	}
+	// Indent the scripts under their group header
+	indent := ""
+	if i.group != "" && !i.favorite {
+		indent = "  "
+		marker = indent + marker
+	}
+	if i.favorite {
+		marker += "★ "
+	}
+	title = truncate.StringWithTail(title, textwidth-uint(lipgloss.Width(marker)), ellipsis)
+	if d.ShowDescription {
+		// The pager and the preview show the whole description
+		descwidth := textwidth - uint(len(indent))
+		if d.MaxDescription > 0 && uint(d.MaxDescription) < descwidth {
+			descwidth = uint(d.MaxDescription)
+		}
+		var lines []string
+		for n, line := range strings.Split(desc, "\n") {
+			if n >= d.Height()-1 {
+				break
+			}
+			lines = append(lines, indent+truncate.StringWithTail(line, descwidth, ellipsis))
+		}
+		desc = strings.Join(lines, "\n")
+	}
+
+	titleStyle, descStyle := s.NormalTitle, s.NormalDesc
+	if index == m.Index() {
+		titleStyle, descStyle = s.SelectedTitle, s.SelectedDesc
+	}
+
+	// Highlight the runes matched by the current filter
+	if len(i.matches) > 0 {
+		unmatched := titleStyle.Copy().Inline(true)
+		matched := d.MatchStyle.Copy().Inherit(unmatched)
+		title = lipgloss.StyleRunes(title, i.matches, matched, unmatched)
+	}
+	title = titleStyle.Render(marker + title)
+	desc = descStyle.Render(desc)
+
+	if d.ShowDescription {
+		fmt.Fprintf(w, "%s\n%s", title, desc)
+		return
+	}
+	fmt.Fprintf(w, "%s", title)
+}
+
+// renderGroupHeader renders a group header as "▾ test", or "▸ test (4)"
+// with the number of scripts hidden when collapsed, padded like items
+func (d itemDelegate) renderGroupHeader(w io.Writer, g groupHeader, selected bool) {
+	style := d.Styles.NormalTitle
+	if selected {
+		style = d.Styles.SelectedTitle
+	}
+	line := "▾ " + g.group
+	if g.collapsed {
+		line = fmt.Sprintf("▸ %s (%d)", g.group, g.count)
+	}
+	fmt.Fprint(w, style.Render(line)+strings.Repeat("\n", d.Height()-1))
+}
+
+// renderHeader renders a source header as "── npm ──", padded to the
+// delegate's height so the list's paging stays right
+func (d itemDelegate) renderHeader(w io.Writer, h header) {
+	padding := strings.Repeat(" ", d.Styles.NormalTitle.GetPaddingLeft())
+	line := padding + d.SourceStyle.Render("── "+h.source+" ──")
+	fmt.Fprint(w, line+strings.Repeat("\n", d.Height()-1))
+}
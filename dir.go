@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// scriptDirs are the directories of executable scripts looked for, in
+// order, when scripts_dir in the config names none
+var scriptDirs = []string{"scripts", ".rx"}
+
+// DirScriptSource handles the executable files of a directory like
+// scripts/, for projects without a task runner
+type DirScriptSource struct {
+	Dir string
+}
+
+// findScriptDir returns configured, the scripts_dir of the config, or else
+// the first of scriptDirs in the current directory, if any
+func findScriptDir(configured string) string {
+	dirs := scriptDirs
+	if configured != "" {
+		dirs = []string{configured}
+	}
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return ""
+}
+
+func (d *DirScriptSource) Name() string {
+	return d.Dir + "/"
+}
+
+func (d *DirScriptSource) GetScripts() ([]list.Item, error) {
+	entries, err := os.ReadDir(d.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", d.Dir, err)
+	}
+
+	items := []list.Item{}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		// Follow symlinks, dotfiles repos are full of them
+		path := filepath.Join(d.Dir, entry.Name())
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+			continue
+		}
+
+		description := fileDescription(path)
+		if description == "" {
+			description = "script in " + d.Name()
+		}
+		items = append(items, item{name: entry.Name(), description: description, command: "./" + filepath.ToSlash(path), source: "scripts"})
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no executable scripts found in %s", d.Name())
+	}
+	return items, nil
+}
+
+// fileDescription returns the "# description:" comment at the top of a
+// script, after its shebang, or "" when it has none
+func fileDescription(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		comment, ok := strings.CutPrefix(line, "#")
+		if !ok {
+			return ""
+		}
+		if description, ok := strings.CutPrefix(strings.TrimSpace(comment), "description:"); ok {
+			return strings.TrimSpace(description)
+		}
+	}
+	return ""
+}
+
+func (d *DirScriptSource) ResolveCommand(name string, extraArgs []string) (string, []string, error) {
+	path, err := filepath.Abs(filepath.Join(d.Dir, name))
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", nil, fmt.Errorf("script %s not found: %w", name, err)
+	}
+
+	// Extra args are passed to the script as they are
+	args := []string{"./" + filepath.ToSlash(filepath.Join(d.Dir, name))}
+	args = append(args, extraArgs...)
+
+	return path, args, nil
+}
+
+func (d *DirScriptSource) RunScript(name string, extraArgs, env []string) error {
+	path, args, err := d.ResolveCommand(name, extraArgs)
+	if err != nil {
+		return err
+	}
+
+	// Replace the current process with the command
+	return runExec(path, args, env)
+}
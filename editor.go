@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// scriptLocator is implemented by sources that can tell where a script is
+// defined, so e can open it in $EDITOR
+type scriptLocator interface {
+	// Locate returns the file defining name and its line, or 0 when only
+	// the file is known
+	Locate(name string) (string, int, error)
+}
+
+// Locate finds the key of a script in the scripts object of package.json,
+// or of the workspace package it belongs to
+func (n *NPMScriptSource) Locate(name string) (string, int, error) {
+	path, script := "package.json", name
+	if ref, ok := n.workspaceScripts[name]; ok {
+		path, script = filepath.Join(ref.Dir, "package.json"), ref.Script
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, err
+	}
+	return path, jsonKeyLine(data, "scripts", script), nil
+}
+
+// Locate finds the rule of a target in the Makefile. Targets from included
+// makefiles open the Makefile at the top.
+func (m *MakefileScriptSource) Locate(name string) (string, int, error) {
+	data, err := os.ReadFile("Makefile")
+	if err != nil {
+		return "", 0, err
+	}
+	return "Makefile", makefileTargetLine(data, name), nil
+}
+
+// Locate finds the task in the rx file, at its top when the line isn't
+// known, as in TOML
+func (r *RxFileScriptSource) Locate(name string) (string, int, error) {
+	path := findRxFile()
+	if path == "" {
+		return "", 0, fmt.Errorf("rx file not found")
+	}
+	return path, r.lines[name], nil
+}
+
+// Locate returns the file of a script in a scripts directory, which is its
+// whole definition
+func (d *DirScriptSource) Locate(name string) (string, int, error) {
+	return filepath.Join(d.Dir, name), 0, nil
+}
+
+// jsonKeyLine returns the line of key inside the object named object, the
+// line of object when key isn't in it, or 0 when neither is found. It scans
+// the text rather than decoding it, so it also works on a file that was
+// just broken by an edit.
+func jsonKeyLine(data []byte, object, key string) int {
+	objectName, _ := json.Marshal(object)
+	start := regexp.MustCompile(regexp.QuoteMeta(string(objectName)) + `\s*:\s*\{`).FindIndex(data)
+	if start == nil {
+		return 0
+	}
+
+	keyName, _ := json.Marshal(key)
+	offset := start[1]
+	if match := regexp.MustCompile(regexp.QuoteMeta(string(keyName)) + `\s*:`).FindIndex(data[offset:]); match != nil {
+		return bytes.Count(data[:offset+match[0]], []byte("\n")) + 1
+	}
+	return bytes.Count(data[:start[0]], []byte("\n")) + 1
+}
+
+// makefileTargetLine returns the line of the first rule with target, or 0
+// when there is none. Recipe lines and variable assignments are skipped.
+func makefileTargetLine(data []byte, target string) int {
+	for index, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "\t") || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		targets, _, ok := strings.Cut(line, ":")
+		if !ok || isMakeAssignment(line) {
+			continue
+		}
+		for _, name := range strings.Fields(targets) {
+			if name == target {
+				return index + 1
+			}
+		}
+	}
+	return 0
+}
+
+// editorArgs builds the command line opening path at line with editor,
+// which may include its own arguments. Most terminal editors take +line,
+// the GUI editors below take path:line instead.
+func editorArgs(editor []string, path string, line int) []string {
+	args := append([]string{}, editor...)
+	if line <= 0 {
+		return append(args, path)
+	}
+
+	switch filepath.Base(editor[0]) {
+	case "code", "code-insiders", "codium", "cursor":
+		return append(args, "--goto", fmt.Sprintf("%s:%d", path, line))
+	case "subl", "zed", "hx", "helix":
+		return append(args, fmt.Sprintf("%s:%d", path, line))
+	default:
+		return append(args, fmt.Sprintf("+%d", line), path)
+	}
+}
+
+// editorFinishedMsg is sent when the editor opened by editScript exits
+// successfully, an errMsg when it fails
+type editorFinishedMsg struct{}
+
+// editScript opens the definition of i in $EDITOR, or vi when it's unset,
+// suspending the TUI until the editor exits
+func (m *model) editScript(i item) tea.Cmd {
+	locator, ok := m.sources[i.source].(scriptLocator)
+	if !ok {
+		return m.setStatus(fmt.Sprintf("Can't edit %s scripts", i.source), true)
+	}
+	path, line, err := locator.Locate(i.name)
+	if err != nil {
+		return m.setStatus("Can't edit "+i.name+": "+err.Error(), true)
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	editorCommand, err := splitArgs(editor)
+	if err != nil || len(editorCommand) == 0 {
+		return m.setStatus(fmt.Sprintf("Invalid $EDITOR %q", editor), true)
+	}
+
+	args := editorArgs(editorCommand, path, line)
+	cmd := exec.Command(args[0], args[1:]...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		if err != nil {
+			return errMsg{fmt.Errorf("editor failed: %w", err)}
+		}
+		return editorFinishedMsg{}
+	})
+}
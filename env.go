@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// envName matches the names of environment variables that can be set
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseEnv parses KEY=VALUE pairs separated by spaces, quoted like shell
+// arguments, as in PORT=3000 MESSAGE="hello world"
+func parseEnv(input string) ([]string, error) {
+	words, err := splitArgs(input)
+	if err != nil {
+		return nil, err
+	}
+	for _, word := range words {
+		name, _, ok := strings.Cut(word, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not KEY=VALUE", word)
+		}
+		if !envName.MatchString(name) {
+			return nil, fmt.Errorf("invalid variable name %q", name)
+		}
+	}
+	return words, nil
+}
+
+// mergeEnv returns base with the KEY=VALUE pairs of overrides, replacing
+// variables of base with the same name. When overrides sets a variable
+// twice, the last one wins. Each variable is set once in the result, as
+// exec doesn't pick the last of duplicates like os/exec does.
+func mergeEnv(base, overrides []string) []string {
+	if len(overrides) == 0 {
+		return base
+	}
+	last := make(map[string]int, len(overrides))
+	for n, pair := range overrides {
+		name, _, _ := strings.Cut(pair, "=")
+		last[name] = n
+	}
+
+	merged := make([]string, 0, len(base)+len(overrides))
+	for _, pair := range base {
+		name, _, _ := strings.Cut(pair, "=")
+		if _, overridden := last[name]; !overridden {
+			merged = append(merged, pair)
+		}
+	}
+	for n, pair := range overrides {
+		if name, _, _ := strings.Cut(pair, "="); last[name] == n {
+			merged = append(merged, pair)
+		}
+	}
+	return merged
+}
+
+// dotenvFiles are loaded into the environment of scripts, later files
+// overriding earlier ones
+var dotenvFiles = []string{".env", ".env.local"}
+
+// loadDotenv reads the variables of the dotenv files in the current
+// directory. Variables already in rx's environment are left out unless
+// override is set.
+func loadDotenv(override bool) ([]string, error) {
+	env := []string{}
+	for _, file := range dotenvFiles {
+		data, err := os.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		pairs, err := parseDotenv(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		env = append(env, pairs...)
+	}
+
+	if override {
+		return env, nil
+	}
+	kept := []string{}
+	for _, pair := range env {
+		name, _, _ := strings.Cut(pair, "=")
+		if _, set := os.LookupEnv(name); !set {
+			kept = append(kept, pair)
+		}
+	}
+	return kept, nil
+}
+
+// parseDotenv parses the KEY=VALUE lines of a .env file. Blank lines,
+// comments and an export prefix are skipped. Values in double quotes may
+// use \n, \" and \\ escapes, values in single quotes are taken as they
+// are, and unquoted values end at a # after a space.
+func parseDotenv(data string) ([]string, error) {
+	env := []string{}
+	for n, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !envName.MatchString(name) {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", n+1)
+		}
+
+		value, err := dotenvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		env = append(env, name+"="+value)
+	}
+	return env, nil
+}
+
+// dotenvValue unquotes the value of a .env line
+func dotenvValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		var unquoted strings.Builder
+		escaped := false
+		for _, r := range value[1:] {
+			switch {
+			case escaped:
+				if r == 'n' {
+					r = '\n'
+				} else if r != '"' && r != '\\' {
+					unquoted.WriteRune('\\')
+				}
+				unquoted.WriteRune(r)
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case r == '"':
+				return unquoted.String(), nil
+			default:
+				unquoted.WriteRune(r)
+			}
+		}
+		return "", fmt.Errorf("unterminated \" quote")
+	case strings.HasPrefix(value, "'"):
+		unquoted, _, ok := strings.Cut(value[1:], "'")
+		if !ok {
+			return "", fmt.Errorf("unterminated ' quote")
+		}
+		return unquoted, nil
+	default:
+		if comment := strings.Index(value, " #"); comment >= 0 {
+			value = strings.TrimSpace(value[:comment])
+		}
+		return value, nil
+	}
+}
+
+// defaultEnv returns the environment configured for items in the env
+// table of the config, sorted by name. Later items win when two set the
+// same variable.
+func defaultEnv(config map[string]map[string]string, items []item) []string {
+	values := make(map[string]string)
+	for _, i := range items {
+		for name, value := range config[i.name] {
+			values[name] = value
+		}
+	}
+
+	env := make([]string, 0, len(values))
+	for name, value := range values {
+		env = append(env, name+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
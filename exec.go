@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// defaultListTimeout limits the commands that list the scripts of a
+// source, like make -pn, unless --timeout or the config say otherwise
+const defaultListTimeout = 10 * time.Second
+
+// errListTimeout is returned by listOutput for a command that ran too long
+var errListTimeout = errors.New("timed out")
+
+// listOutput runs a command that lists scripts and returns its stdout. It's
+// killed, with any processes it started, when it runs longer than timeout,
+// so a stuck build tool can't keep rx from starting. A timeout of 0 waits
+// forever. Scripts themselves are never run with a timeout.
+func listOutput(timeout time.Duration, name string, args ...string) ([]byte, error) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		return stopProcess(cmd, true)
+	}
+	// Don't wait for children that still hold the output open
+	cmd.WaitDelay = time.Second
+
+	output, err := cmd.Output()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w after %v, raise the limit with --timeout or timeout in the config", errListTimeout, timeout)
+	}
+	return output, err
+}
+
+// exitCode returns the status a shell would report for a finished process,
+// 128 plus the signal number when it was killed by a signal
+func exitCode(state *os.ProcessState) int {
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return state.ExitCode()
+}
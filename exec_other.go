@@ -0,0 +1,46 @@
+//go:build !unix
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+)
+
+// runExec runs the command as a child process where the current process
+// can't be replaced, passing rx's terminal through to it. Like exec, it
+// only returns if the command couldn't be started: otherwise rx exits with
+// the command's exit code. env overrides variables of rx's environment.
+func runExec(path string, args, env []string) error {
+	cmd := exec.Command(path, args[1:]...)
+	cmd.Args = args
+	cmd.Env = mergeEnv(os.Environ(), env)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// ctrl+c reaches the command too, which decides whether it stops,
+	// while rx waits to exit with its code
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return err
+	}
+	os.Exit(exitCode(cmd.ProcessState))
+	return nil
+}
+
+// setProcessGroup does nothing, process groups are a Unix feature
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// stopProcess kills a command started by watch mode. Without Unix signals
+// there is no gentler way to ask it to stop.
+func stopProcess(cmd *exec.Cmd, force bool) error {
+	return cmd.Process.Kill()
+}
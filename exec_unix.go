@@ -0,0 +1,32 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// runExec replaces the current process with the command, so the script
+// gets rx's terminal and signals directly. env overrides variables of rx's
+// environment. It only returns on failure.
+func runExec(path string, args, env []string) error {
+	return syscall.Exec(path, args, mergeEnv(os.Environ(), env))
+}
+
+// setProcessGroup starts cmd in a process group of its own, so watch mode
+// can stop the processes it starts too, and ctrl+c only reaches rx
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// stopProcess sends SIGTERM to the process group of a command started by
+// watch mode, or SIGKILL when force is set
+func stopProcess(cmd *exec.Cmd, force bool) error {
+	sig := syscall.SIGTERM
+	if force {
+		sig = syscall.SIGKILL
+	}
+	return syscall.Kill(-cmd.Process.Pid, sig)
+}
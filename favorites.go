@@ -0,0 +1,93 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// favorite identifies a starred script. The source is part of it so a make
+// target and an npm script with the same name are starred separately.
+type favorite struct {
+	Source string `json:"source"`
+	Name   string `json:"name"`
+}
+
+// favoriteKey returns the key of a script in a favorites set
+func favoriteKey(source, name string) string {
+	return source + "\x00" + name
+}
+
+// loadAllFavorites reads the favorites of every directory, keyed by
+// directory
+func loadAllFavorites() (map[string][]favorite, error) {
+	path, err := statePath("favorites.json")
+	if err != nil {
+		return nil, err
+	}
+	all := make(map[string][]favorite)
+	if err := loadJSON(path, &all); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// loadFavorites returns the set of scripts starred in dir
+func loadFavorites(dir string) (map[string]bool, error) {
+	all, err := loadAllFavorites()
+	if err != nil {
+		return nil, err
+	}
+	favorites := make(map[string]bool)
+	for _, f := range all[dir] {
+		favorites[favoriteKey(f.Source, f.Name)] = true
+	}
+	return favorites, nil
+}
+
+// saveFavorites replaces the favorites of dir, keeping other directories
+func saveFavorites(dir string, favorites map[string]bool) error {
+	entries := []favorite{}
+	for key := range favorites {
+		source, name := splitFavoriteKey(key)
+		entries = append(entries, favorite{Source: source, Name: name})
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		return favoriteKey(entries[a].Source, entries[a].Name) < favoriteKey(entries[b].Source, entries[b].Name)
+	})
+
+	path, err := statePath("favorites.json")
+	if err != nil {
+		return err
+	}
+	return updateJSON(path, func() error {
+		all, err := loadAllFavorites()
+		if err != nil {
+			// Start over rather than losing the toggle on a corrupt file
+			all = make(map[string][]favorite)
+		}
+		if len(entries) == 0 {
+			delete(all, dir)
+		} else {
+			all[dir] = entries
+		}
+		return saveJSON(path, all)
+	})
+}
+
+// splitFavoriteKey is the inverse of favoriteKey
+func splitFavoriteKey(key string) (string, string) {
+	source, name, _ := strings.Cut(key, "\x00")
+	return source, name
+}
+
+// markFavorites sets the favorite flag of items in the favorites set
+func markFavorites(items []list.Item, favorites map[string]bool) {
+	for n, listItem := range items {
+		if i, ok := listItem.(item); ok {
+			i.favorite = favorites[favoriteKey(i.source, i.name)]
+			items[n] = i
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+// loadAllFilters reads the last filter of every directory, keyed by
+// directory
+func loadAllFilters() (map[string]string, error) {
+	path, err := statePath("filters.json")
+	if err != nil {
+		return nil, err
+	}
+	all := make(map[string]string)
+	if err := loadJSON(path, &all); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// loadFilter returns the filter rx was last left with in dir, for
+// remember_filter
+func loadFilter(dir string) (string, error) {
+	all, err := loadAllFilters()
+	if err != nil {
+		return "", err
+	}
+	return all[dir], nil
+}
+
+// saveFilter remembers the filter of dir, keeping other directories. An
+// empty filter forgets it, so clearing a restored filter sticks.
+func saveFilter(dir, filter string) error {
+	path, err := statePath("filters.json")
+	if err != nil {
+		return err
+	}
+	return updateJSON(path, func() error {
+		all, err := loadAllFilters()
+		if err != nil {
+			// Start over rather than failing on a corrupt file
+			all = make(map[string]string)
+		}
+		if all[dir] == filter {
+			return nil
+		}
+		if filter == "" {
+			delete(all, dir)
+		} else {
+			all[dir] = filter
+		}
+		return saveJSON(path, all)
+	})
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+)
+
+// scriptFlags returns the flags the config offers for items, from its
+// flags table keyed by script name. Flags are only offered when a single
+// script runs, as the chosen ones are passed to every script.
+func scriptFlags(config map[string][]string, items []item) []string {
+	if len(items) != 1 {
+		return nil
+	}
+	return config[items[0].name]
+}
+
+// openFlags asks which of flags to pass to items before running them with
+// args
+func (m model) openFlags(items []item, args, flags []string) (model, tea.Cmd) {
+	m.pending = items
+	m.pendingArgs = args
+	chosen := []string{}
+	m.flagsField = huh.NewMultiSelect[string]().
+		Title("Flags for " + itemNames(items)).
+		Options(huh.NewOptions(flags...)...).
+		Value(&chosen).
+		Key("flags")
+	m.flagsForm = huh.NewForm(huh.NewGroup(m.flagsField)).WithShowHelp(false).WithShowErrors(false)
+	m.flagsFocused = true
+	return m, m.flagsForm.Init()
+}
+
+// pickFlags finishes the flags prompt, running the pending items with the
+// chosen flags after their arguments, or without any when none are chosen
+func (m model) pickFlags(chosen []string) (model, tea.Cmd) {
+	m.flagsFocused = false
+	args := append(append([]string{}, m.pendingArgs...), chosen...)
+	return m.confirmItems(m.pending, args)
+}
+
+// flagsView renders the flags prompt with the keys to use it
+func (m model) flagsView() string {
+	return m.flagsForm.View() + "\n" + helpStyle.Render("space: toggle • enter: run • esc: back to the list")
+}
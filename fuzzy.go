@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Scoring weights for fuzzyMatch
+const (
+	substringBonus   = 1000 // a literal substring always beats a scattered match
+	prefixBonus      = 100  // the match starts at the beginning of the text
+	consecutiveBonus = 15   // the rune directly follows the previous match
+	boundaryBonus    = 10   // the rune starts a word (after -, _, :, space, etc.)
+	gapPenalty       = 1    // per skipped rune between matches
+)
+
+// fuzzyMatch reports whether pattern matches text as a case-insensitive
+// subsequence. It returns a score (higher is better) and the rune indices
+// in text that matched, which callers can use for highlighting.
+func fuzzyMatch(pattern, text string) (int, []int, bool) {
+	if pattern == "" {
+		return 0, nil, true
+	}
+
+	lowerPattern := []rune(strings.ToLower(pattern))
+	lowerText := []rune(strings.ToLower(text))
+
+	// Prefer an exact substring match
+	if idx := indexRunes(lowerText, lowerPattern); idx >= 0 {
+		positions := make([]int, len(lowerPattern))
+		for i := range positions {
+			positions[i] = idx + i
+		}
+		score := substringBonus + consecutiveBonus*(len(lowerPattern)-1) - idx
+		if idx == 0 {
+			score += prefixBonus
+		} else if isBoundary(lowerText, idx) {
+			score += boundaryBonus
+		}
+		return score, positions, true
+	}
+
+	// Fall back to an in-order subsequence match
+	positions := make([]int, 0, len(lowerPattern))
+	score := 0
+	p := 0
+	last := -1
+	for i, r := range lowerText {
+		if p == len(lowerPattern) {
+			break
+		}
+		if r != lowerPattern[p] {
+			continue
+		}
+		if last >= 0 && i == last+1 {
+			score += consecutiveBonus
+		} else if last >= 0 {
+			score -= gapPenalty * (i - last - 1)
+		}
+		if i == 0 {
+			score += prefixBonus
+		} else if isBoundary(lowerText, i) {
+			score += boundaryBonus
+		}
+		positions = append(positions, i)
+		last = i
+		p++
+	}
+
+	if p < len(lowerPattern) {
+		return 0, nil, false
+	}
+	return score, positions, true
+}
+
+// indexRunes returns the index of the first occurrence of sub in s, or -1
+func indexRunes(s, sub []rune) int {
+	for i := 0; i+len(sub) <= len(s); i++ {
+		match := true
+		for j := range sub {
+			if s[i+j] != sub[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
+// isBoundary reports whether the rune at i starts a new word
+func isBoundary(text []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev := text[i-1]
+	return !unicode.IsLetter(prev) && !unicode.IsDigit(prev)
+}
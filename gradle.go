@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// gradleBuildFiles are the build scripts that mark a Gradle project
+var gradleBuildFiles = []string{"build.gradle", "build.gradle.kts"}
+
+// GradleScriptSource handles tasks of a Gradle build
+type GradleScriptSource struct {
+	Timeout time.Duration // limit for listing tasks, 0 for none
+}
+
+func (g *GradleScriptSource) Name() string {
+	return "gradle"
+}
+
+func (g *GradleScriptSource) GetScripts() ([]list.Item, error) {
+	gradlePath, err := findGradle()
+	if err != nil {
+		return nil, err
+	}
+
+	// List every task, including those not in a group
+	output, err := listOutput(g.Timeout, gradlePath, "tasks", "--all", "--quiet", "--console=plain")
+	if err != nil {
+		return nil, fmt.Errorf("error running %s tasks: %w", gradleCommand(), err)
+	}
+
+	items := parseGradleTasks(string(output))
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no tasks found in Gradle build")
+	}
+
+	return items, nil
+}
+
+// CommandArgs returns the gradle command line running name, without
+// looking for gradle
+func (g *GradleScriptSource) CommandArgs(name string, extraArgs []string) ([]string, error) {
+	// Prepare arguments for gradle, extra args are appended directly
+	args := []string{gradleCommand(), name}
+	args = append(args, extraArgs...)
+
+	return args, nil
+}
+
+func (g *GradleScriptSource) ResolveCommand(name string, extraArgs []string) (string, []string, error) {
+	gradlePath, err := findGradle()
+	if err != nil {
+		return "", nil, err
+	}
+
+	args, err := g.CommandArgs(name, extraArgs)
+	if err != nil {
+		return "", nil, err
+	}
+	return gradlePath, args, nil
+}
+
+func (g *GradleScriptSource) RunScript(name string, extraArgs, env []string) error {
+	path, args, err := g.ResolveCommand(name, extraArgs)
+	if err != nil {
+		return err
+	}
+
+	// Replace the current process with the command
+	return runExec(path, args, env)
+}
+
+// isGradleProject reports whether the current directory has a Gradle build
+// script or wrapper
+func isGradleProject() bool {
+	for _, name := range append(gradleBuildFiles, "gradlew") {
+		if _, err := os.Stat(name); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// gradleCommand returns how gradle is invoked, preferring the wrapper
+func gradleCommand() string {
+	if _, err := os.Stat("gradlew"); err == nil {
+		return "./gradlew"
+	}
+	return "gradle"
+}
+
+// findGradle returns the path to the Gradle wrapper when the project has
+// one, otherwise to gradle on PATH
+func findGradle() (string, error) {
+	if _, err := os.Stat("gradlew"); err == nil {
+		return filepath.Abs("gradlew")
+	}
+	gradlePath, err := exec.LookPath("gradle")
+	if err != nil {
+		return "", fmt.Errorf("gradle not found: %w", err)
+	}
+	return gradlePath, nil
+}
+
+// parseGradleTasks extracts tasks from gradle tasks --all output, where
+// tasks are grouped under underlined headers:
+//
+//	Build tasks
+//	-----------
+//	assemble - Assembles the outputs of this project.
+//	build - Assembles and tests this project.
+//
+// Tasks without a description are described by their group.
+func parseGradleTasks(output string) []list.Item {
+	items := []list.Item{}
+	lines := strings.Split(output, "\n")
+
+	group := ""
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \r")
+
+		// A header is underlined with dashes on the next line
+		if i+1 < len(lines) && isGradleUnderline(lines[i+1]) && !isGradleUnderline(line) {
+			group = line
+			i++
+			continue
+		}
+		// Skip the banner, blank lines and anything before the first group.
+		// Rules describe task name patterns rather than tasks.
+		if group == "" || group == "Rules" || line == "" || isGradleUnderline(line) {
+			continue
+		}
+		// Everything after the groups is help text
+		if strings.HasPrefix(line, "To see ") {
+			group = ""
+			continue
+		}
+
+		name, description, _ := strings.Cut(line, " - ")
+		name = strings.TrimSpace(name)
+		if name == "" || strings.Contains(name, " ") {
+			continue
+		}
+		if description == "" {
+			description = group
+		}
+		items = append(items, item{name: name, description: description, command: gradleCommand() + " " + name, source: "gradle"})
+	}
+
+	return items
+}
+
+// isGradleUnderline reports whether line underlines a header
+func isGradleUnderline(line string) bool {
+	line = strings.TrimSpace(line)
+	return line != "" && strings.Trim(line, "-") == ""
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// generalGroup holds the scripts of a source outside any namespace
+const generalGroup = "general"
+
+// groupHeader is a row that starts a group of scripts within a source, like
+// the test:* scripts of package.json. z on one of them collapses the group,
+// whose header can then be selected to expand it with z or enter.
+type groupHeader struct {
+	source    string
+	group     string
+	count     int
+	collapsed bool
+}
+
+func (g groupHeader) FilterValue() string { return "" }
+
+// groupKey identifies the group of a source in model.collapsed
+func groupKey(source, group string) string {
+	return source + "\x00" + group
+}
+
+// assignGroups groups npm scripts by the namespace before the first colon,
+// so test, test:unit and test:e2e make up the test group, and the scripts
+// of a workspace package by the package. The rest go in generalGroup. When
+// everything would end up in one group nothing is grouped.
+func assignGroups(items []list.Item, workspaces map[string]workspaceScript) {
+	namespaces := make(map[string]bool)
+	for _, listItem := range items {
+		i := listItem.(item)
+		if namespace, _, ok := strings.Cut(i.name, ":"); ok {
+			namespaces[namespace] = true
+		}
+	}
+
+	groups := make(map[string]bool)
+	for index, listItem := range items {
+		i := listItem.(item)
+		namespace, _, _ := strings.Cut(i.name, ":")
+		switch {
+		case workspaces[i.name].Workspace != "":
+			i.group = workspaces[i.name].Workspace
+		case namespaces[namespace]:
+			i.group = namespace
+		default:
+			i.group = generalGroup
+		}
+		groups[i.group] = true
+		items[index] = i
+	}
+
+	if len(groups) > 1 {
+		return
+	}
+	for index, listItem := range items {
+		i := listItem.(item)
+		i.group = ""
+		items[index] = i
+	}
+}
+
+// groupScripts puts the items of one source under a header for each of
+// their groups, in the order of each group's first item. Collapsed groups
+// only show their header.
+func groupScripts(source string, items []list.Item, collapsed map[string]bool) []list.Item {
+	var order []string
+	groups := make(map[string][]list.Item)
+	for _, listItem := range items {
+		group := listItem.(item).group
+		if group == "" {
+			return items
+		}
+		if _, seen := groups[group]; !seen {
+			order = append(order, group)
+		}
+		groups[group] = append(groups[group], listItem)
+	}
+
+	grouped := make([]list.Item, 0, len(items)+len(order))
+	for _, group := range order {
+		isCollapsed := collapsed[groupKey(source, group)]
+		grouped = append(grouped, groupHeader{source: source, group: group, count: len(groups[group]), collapsed: isCollapsed})
+		if !isCollapsed {
+			grouped = append(grouped, groups[group]...)
+		}
+	}
+	return grouped
+}
+
+// hasGroups reports whether the list shows any group
+func (m model) hasGroups() bool {
+	for _, listItem := range m.list.Items() {
+		if _, ok := listItem.(groupHeader); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// toggleGroup collapses the group of the selected script, selecting its
+// header, or expands the selected group and selects its first script
+func (m *model) toggleGroup() {
+	var source, group string
+	switch selected := m.list.SelectedItem().(type) {
+	case groupHeader:
+		source, group = selected.source, selected.group
+	case item:
+		if selected.group == "" || selected.favorite {
+			return
+		}
+		source, group = selected.source, selected.group
+	default:
+		return
+	}
+
+	if m.collapsed == nil {
+		m.collapsed = make(map[string]bool)
+	}
+	key := groupKey(source, group)
+	m.collapsed[key] = !m.collapsed[key]
+	m.applyFilter()
+
+	for index, listItem := range m.list.Items() {
+		if h, ok := listItem.(groupHeader); ok && h.source == source && h.group == group {
+			m.list.Select(index)
+			m.skipHeader(true)
+			return
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// helpEntry is a line of the help screen, what keys does
+type helpEntry struct {
+	keys        string
+	description string
+}
+
+// openHelp shows every key in the pager, over the list, until ? or esc
+func (m *model) openHelp() {
+	m.viewing = true
+	m.viewingHelp = true
+	m.pager = viewport.New(m.width, max(m.height-pagerHelpHeight, 1))
+	m.setPagerContent()
+}
+
+// helpSection is a titled group of help entries
+type helpSection struct {
+	title   string
+	entries []helpEntry
+}
+
+// helpSections lists the keys of the filter and the list, as bound
+func (m model) helpSections() []helpSection {
+	filter := []helpEntry{
+		{m.keys.help("filter"), "Focus the filter"},
+		{"enter", "Move to the list, keeping the highlighted match"},
+		{"ctrl+n/ctrl+p", "Move through the matches"},
+		{"tab, down", "Move to the list and down it"},
+		{"esc", "Move to the list"},
+		{"ctrl+d", "Also match descriptions"},
+		{"ctrl+u", "Clear the filter"},
+	}
+	list := []helpEntry{
+		{"↑/↓ or " + m.keys.first("up") + "/" + m.keys.first("down"), "Move through the scripts"},
+		{"g/G", "Jump to the first or last script"},
+		{m.keys.help("run"), "Run the selected script, or the queued scripts"},
+		{"space", "Queue the selected script"},
+		{"a", "Run with extra arguments"},
+		{"E", "Run with environment variables"},
+		{"p", "Toggle dry run, printing the command instead"},
+		{"c", "Copy the command"},
+		{"v", "View the whole definition"},
+		{"e", "Edit the script in $EDITOR"},
+		{"f", "Star the script, listing it first"},
+		{"z", "Fold or unfold the script's group"},
+		{m.keys.help("reload"), "Read the scripts again"},
+		{"esc", "Clear the filter, then the queue, then quit"},
+		{m.keys.help("quit"), "Quit, press twice while filtering"},
+		{"?", "Show or hide this help"},
+		{"ctrl+c", "Quit from anywhere"},
+	}
+	return []helpSection{{"In the filter", filter}, {"In the list", list}}
+}
+
+// helpContent is the help screen: the version, the sources in use and
+// every key, with the keys rebound in the config as they are bound
+func (m model) helpContent() string {
+	sources := strings.Join(sourceNames(m.sources, m.allItems), ", ")
+	if sources == "" {
+		sources = "none"
+	}
+	lines := []string{
+		titleStyle.Copy().UnsetMargins().Render(versionString()),
+		"",
+		helpStyle.Render("Scripts from " + sources + " in " + m.dir),
+	}
+
+	sections := m.helpSections()
+	width := 0
+	for _, section := range sections {
+		for _, entry := range section.entries {
+			width = max(width, lipgloss.Width(entry.keys))
+		}
+	}
+	for _, section := range sections {
+		lines = append(lines, "", helpStyle.Render(section.title))
+		for _, entry := range section.entries {
+			keys := previewStyle.Render(fmt.Sprintf("%-*s", width, entry.keys))
+			lines = append(lines, "  "+keys+"  "+entry.description)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
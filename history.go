@@ -0,0 +1,128 @@
+package main
+
+import (
+	"os"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// maxHistoryEntries caps the number of scripts remembered across directories
+const maxHistoryEntries = 100
+
+// historyEntry records a script that was run from rx
+type historyEntry struct {
+	Dir    string    `json:"dir"`
+	Name   string    `json:"name"`
+	Source string    `json:"source"`
+	Time   time.Time `json:"time"`
+}
+
+// loadHistory reads the run history, most recent first
+func loadHistory() ([]historyEntry, error) {
+	path, err := statePath("history.json")
+	if err != nil {
+		return nil, err
+	}
+	history := []historyEntry{}
+	if err := loadJSON(path, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// recordHistory adds a run to the front of the history, replacing any
+// earlier entry for the same directory and script name
+func recordHistory(dir, name, source string) error {
+	path, err := statePath("history.json")
+	if err != nil {
+		return err
+	}
+	return updateJSON(path, func() error {
+		history, err := loadHistory()
+		if err != nil {
+			// Start over rather than failing the run on a corrupt file
+			history = []historyEntry{}
+		}
+
+		updated := []historyEntry{{Dir: dir, Name: name, Source: source, Time: time.Now()}}
+		for _, entry := range history {
+			if entry.Dir == dir && entry.Name == name {
+				continue
+			}
+			updated = append(updated, entry)
+		}
+		if len(updated) > maxHistoryEntries {
+			updated = updated[:maxHistoryEntries]
+		}
+		return saveJSON(path, updated)
+	})
+}
+
+// clearHistory removes the history file, under the same lock as
+// recordHistory so a run recorded meanwhile can't write it back
+func clearHistory() error {
+	path, err := statePath("history.json")
+	if err != nil {
+		return err
+	}
+	return updateJSON(path, func() error {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	})
+}
+
+// ranIn reports whether the history has any run in dir
+func ranIn(history []historyEntry, dir string) bool {
+	for _, entry := range history {
+		if entry.Dir == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// sortByHistory moves scripts recently run in dir to the top, most recent
+// first, leaving the rest in their original order
+func sortByHistory(items []list.Item, history []historyEntry, dir string) []list.Item {
+	// Rank scripts by how recently they ran, lower is more recent
+	rank := make(map[string]int)
+	for _, entry := range history {
+		if entry.Dir != dir {
+			continue
+		}
+		key := entry.Source + "\x00" + entry.Name
+		if _, ok := rank[key]; !ok {
+			rank[key] = len(rank)
+		}
+	}
+	if len(rank) == 0 {
+		return items
+	}
+
+	sorted := make([]list.Item, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(a, b int) bool {
+		rankA, okA := historyRank(rank, sorted[a])
+		rankB, okB := historyRank(rank, sorted[b])
+		if okA != okB {
+			return okA
+		}
+		return okA && rankA < rankB
+	})
+	return sorted
+}
+
+// historyRank looks up an item's position in rank, the recent history or
+// the stats
+func historyRank(rank map[string]int, listItem list.Item) (int, bool) {
+	i, ok := listItem.(item)
+	if !ok {
+		return 0, false
+	}
+	r, ok := rank[i.source+"\x00"+i.name]
+	return r, ok
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// ignoreFileName is the file listing scripts to hide from rx
+const ignoreFileName = ".rxignore"
+
+// ignoreRule is a pattern of .rxignore. Rules are applied in order and the
+// last one matching a script decides, so a negated rule re-includes scripts
+// hidden by an earlier one.
+type ignoreRule struct {
+	pattern string
+	negate  bool   // the pattern started with !
+	source  string // only applies to items of this source when set
+}
+
+// defaultIgnoreRules hide npm's lifecycle scripts, which npm runs itself
+// around install, publish and version. They come before the rules of
+// .rxignore, so !prepare shows prepare again.
+var defaultIgnoreRules = func() []ignoreRule {
+	hooks := []string{
+		"preinstall", "postinstall",
+		"preprepare", "prepare", "postprepare",
+		"prepublish", "prepublishOnly", "postpublish",
+		"prepack", "postpack",
+		"preversion", "postversion",
+		"dependencies",
+	}
+	rules := make([]ignoreRule, len(hooks))
+	for i, hook := range hooks {
+		rules[i] = ignoreRule{pattern: hook, source: "npm"}
+	}
+	return rules
+}()
+
+// loadIgnoreRules reads the .rxignore in the current directory, after the
+// default rules when defaults is set. Blank lines and lines starting with #
+// are skipped.
+func loadIgnoreRules(defaults bool) ([]ignoreRule, error) {
+	rules := []ignoreRule{}
+	if defaults {
+		rules = append(rules, defaultIgnoreRules...)
+	}
+
+	f, err := os.Open(ignoreFileName)
+	if os.IsNotExist(err) {
+		return rules, nil
+	}
+	if err != nil {
+		return rules, fmt.Errorf("error reading %s: %w", ignoreFileName, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		pattern := strings.TrimSpace(scanner.Text())
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		rule := ignoreRule{}
+		if negated, ok := strings.CutPrefix(pattern, "!"); ok {
+			rule.negate = true
+			pattern = negated
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return rules, fmt.Errorf("%s:%d: invalid pattern %q", ignoreFileName, line, pattern)
+		}
+		rule.pattern = pattern
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return rules, fmt.Errorf("error reading %s: %w", ignoreFileName, err)
+	}
+	return rules, nil
+}
+
+// isIgnored reports whether the last rule matching i hides it
+func isIgnored(rules []ignoreRule, i item) bool {
+	ignored := false
+	for _, rule := range rules {
+		if rule.source != "" && rule.source != i.source {
+			continue
+		}
+		if ok, _ := path.Match(rule.pattern, i.name); ok {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// removeIgnored returns the items the rules don't hide
+func removeIgnored(items []list.Item, rules []ignoreRule) []list.Item {
+	kept := items[:0]
+	for _, listItem := range items {
+		if i, ok := listItem.(item); ok && isIgnored(rules, i) {
+			continue
+		}
+		kept = append(kept, listItem)
+	}
+	return kept
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+)
+
+// sourceFileError is a script source's file that was found but can't be
+// read, as opposed to one without scripts. rx reports it rather than
+// looking on as if the file wasn't there.
+type sourceFileError struct {
+	File string
+	Line int // 0 when the line isn't known
+	Err  error
+}
+
+func (e *sourceFileError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.File, e.Err)
+}
+
+func (e *sourceFileError) Unwrap() error {
+	return e.Err
+}
+
+// scriptsError is a scripts object of the wrong shape. Script is the script
+// whose command isn't a string, or empty when scripts isn't an object.
+type scriptsError struct {
+	Script string
+	Found  string
+}
+
+func (e *scriptsError) Error() string {
+	if e.Script != "" {
+		return fmt.Sprintf("script %q must be a command string, found %s", e.Script, e.Found)
+	}
+	return fmt.Sprintf(`"scripts" must be an object of script names and commands, found %s`, e.Found)
+}
+
+// jsonKind names the type of a JSON value for error messages
+func jsonKind(raw []byte) string {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return "nothing"
+	}
+	switch raw[0] {
+	case '{':
+		return "an object"
+	case '[':
+		return "an array"
+	case '"':
+		return "a string"
+	case 't', 'f':
+		return "a boolean"
+	case 'n':
+		return "null"
+	default:
+		return "a number"
+	}
+}
+
+// goKind names the JSON type a Go value is decoded from
+func goKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	default:
+		return "a number"
+	}
+}
+
+// valueKind names the JSON type of json.UnmarshalTypeError.Value
+func valueKind(value string) string {
+	switch value {
+	case "object", "array":
+		return "an " + value
+	case "bool":
+		return "a boolean"
+	default:
+		return "a " + value
+	}
+}
+
+// packageJSONError turns an error decoding the package.json at path into a
+// sourceFileError saying what's wrong and on which line
+func packageJSONError(path string, data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var shapeErr *scriptsError
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset := int(syntaxErr.Offset) - 1
+		if comma := trailingComma(data, offset); comma >= 0 {
+			return &sourceFileError{File: path, Line: lineAt(data, comma), Err: errors.New("trailing comma, JSON doesn't allow a comma before } or ]")}
+		}
+		return &sourceFileError{File: path, Line: lineAt(data, offset), Err: syntaxErr}
+	case errors.As(err, &typeErr):
+		return &sourceFileError{
+			File: path,
+			Line: lineAt(data, int(typeErr.Offset)-1),
+			Err:  fmt.Errorf("%q must be %s, found %s", typeErr.Field, goKind(typeErr.Type), valueKind(typeErr.Value)),
+		}
+	case errors.As(err, &shapeErr):
+		line := jsonFieldLine(data, "scripts")
+		if shapeErr.Script != "" {
+			line = jsonKeyLine(data, "scripts", shapeErr.Script)
+		}
+		return &sourceFileError{File: path, Line: line, Err: shapeErr}
+	}
+	return &sourceFileError{File: path, Err: err}
+}
+
+// trailingComma returns the offset of the comma before the } or ] at
+// offset, or -1 when the syntax error there isn't a trailing comma
+func trailingComma(data []byte, offset int) int {
+	if offset < 0 || offset >= len(data) || (data[offset] != '}' && data[offset] != ']') {
+		return -1
+	}
+	before := bytes.TrimRight(data[:offset], " \t\r\n")
+	if len(before) == 0 || before[len(before)-1] != ',' {
+		return -1
+	}
+	return len(before) - 1
+}
+
+// lineAt returns the line of the byte at offset
+func lineAt(data []byte, offset int) int {
+	offset = min(max(offset, 0), len(data))
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// jsonFieldLine returns the line of the first key named field, or 0
+func jsonFieldLine(data []byte, field string) int {
+	name, _ := json.Marshal(field)
+	match := regexp.MustCompile(regexp.QuoteMeta(string(name)) + `\s*:`).FindIndex(data)
+	if match == nil {
+		return 0
+	}
+	return lineAt(data, match[0])
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// justfileNames are the file names just looks for, in order
+var justfileNames = []string{"justfile", "Justfile", ".justfile"}
+
+// JustScriptSource handles recipes from a justfile
+type JustScriptSource struct {
+	Timeout time.Duration // limit for just --list, 0 for none
+}
+
+func (j *JustScriptSource) Name() string {
+	return "justfile"
+}
+
+func (j *JustScriptSource) GetScripts() ([]list.Item, error) {
+	// Check if a justfile exists
+	if findJustfile() == "" {
+		return nil, fmt.Errorf("justfile not found")
+	}
+
+	// Run just --list to get recipes with their doc comments
+	output, err := listOutput(j.Timeout, "just", "--list", "--unsorted")
+	if err != nil {
+		return nil, fmt.Errorf("error running just --list: %w", err)
+	}
+
+	items := parseJustList(string(output))
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no recipes found in justfile")
+	}
+
+	return items, nil
+}
+
+// CommandArgs returns the just command line running name, without
+// looking for just
+func (j *JustScriptSource) CommandArgs(name string, extraArgs []string) ([]string, error) {
+	// Prepare arguments for just, extra args are passed as recipe arguments
+	args := []string{"just", name}
+	args = append(args, extraArgs...)
+
+	return args, nil
+}
+
+func (j *JustScriptSource) ResolveCommand(name string, extraArgs []string) (string, []string, error) {
+	// Find the path to just executable
+	justPath, err := exec.LookPath("just")
+	if err != nil {
+		return "", nil, fmt.Errorf("just not found: %w", err)
+	}
+
+	args, err := j.CommandArgs(name, extraArgs)
+	if err != nil {
+		return "", nil, err
+	}
+	return justPath, args, nil
+}
+
+func (j *JustScriptSource) RunScript(name string, extraArgs, env []string) error {
+	path, args, err := j.ResolveCommand(name, extraArgs)
+	if err != nil {
+		return err
+	}
+
+	// Replace the current process with the command
+	return runExec(path, args, env)
+}
+
+// findJustfile returns the justfile in the current directory, if any
+func findJustfile() string {
+	for _, name := range justfileNames {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// parseJustList extracts recipes from just --list output, which looks like:
+//
+//	Available recipes:
+//	    build target="debug" # Build the project
+//	    test
+func parseJustList(output string) []list.Item {
+	items := []list.Item{}
+
+	for _, line := range strings.Split(output, "\n") {
+		// Recipes are indented, the header and blank lines are not
+		if !strings.HasPrefix(line, " ") {
+			continue
+		}
+		line = strings.TrimSpace(line)
+
+		// Skip group headings like [build]
+		if line == "" || strings.HasPrefix(line, "[") {
+			continue
+		}
+
+		signature, doc, _ := strings.Cut(line, " # ")
+		fields := strings.Fields(signature)
+		if len(fields) == 0 {
+			continue
+		}
+
+		description := strings.TrimSpace(doc)
+		if description == "" {
+			description = "just recipe"
+		}
+		items = append(items, item{name: fields[0], description: description, command: "just " + signature, source: "just"})
+	}
+
+	return items
+}
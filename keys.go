@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// keyActions are the actions that can be rebound in the config's [keys]
+var keyActions = []string{"filter", "quit", "run", "up", "down", "reload"}
+
+// defaultKeys are the keys of each action unless the config rebinds it. The
+// arrow keys always move up and down too.
+var defaultKeys = keyMap{
+	"filter": {"/", "ctrl+f"},
+	"quit":   {"q"},
+	"run":    {"enter"},
+	"up":     {"k"},
+	"down":   {"j"},
+	"reload": {"r"},
+}
+
+// fixedKeys are the keys of the list that can't be rebound, so no action
+// can take them
+var fixedKeys = []string{"ctrl+c", "esc", "ctrl+d", "up", "down", "p", "g", "G", "f", "e", "c", "v", " ", "E", "a", "z", "?"}
+
+// namedKeys are the keys that aren't a single character, as Bubble Tea
+// names them
+var namedKeys = []string{
+	"enter", "tab", "shift+tab", "backspace", "delete", "insert", "left", "right",
+	"up", "down", "home", "end", "pgup", "pgdown",
+	"f1", "f2", "f3", "f4", "f5", "f6", "f7", "f8", "f9", "f10", "f11", "f12",
+}
+
+// keyMap maps each action to its keys
+type keyMap map[string][]string
+
+// keyList is the keys of an action in the config, a single key or a list
+type keyList []string
+
+func (k *keyList) UnmarshalTOML(value interface{}) error {
+	switch value := value.(type) {
+	case string:
+		*k = keyList{value}
+	case []interface{}:
+		*k = keyList{}
+		for _, key := range value {
+			s, ok := key.(string)
+			if !ok {
+				return fmt.Errorf("keys must be strings, found %v", key)
+			}
+			*k = append(*k, s)
+		}
+	default:
+		return fmt.Errorf("must be a key or a list of keys, found %v", value)
+	}
+	return nil
+}
+
+// validKey reports whether key is a key name Bubble Tea reports: a single
+// character, a named key, or one of them with ctrl+ or alt+
+func validKey(key string) bool {
+	if utf8.RuneCountInString(key) == 1 {
+		return true
+	}
+	if contains(namedKeys, key) {
+		return true
+	}
+	for _, modifier := range []string{"ctrl+", "alt+"} {
+		if rest, ok := strings.CutPrefix(key, modifier); ok && rest != "" && !strings.HasPrefix(rest, "ctrl+") {
+			return validKey(rest)
+		}
+	}
+	return false
+}
+
+// checkKeys returns the bindings of the config's [keys] that can be used,
+// with a warning for each that can't: unknown actions, invalid keys, and
+// keys taken by a fixed key or another action, which keep their default
+func checkKeys(bindings map[string]keyList) (map[string]keyList, []string) {
+	var warnings []string
+	checked := make(map[string]keyList)
+	for _, action := range sortedKeys(bindings) {
+		keys := bindings[action]
+		if !contains(keyActions, action) {
+			warnings = append(warnings, fmt.Sprintf("keys: unknown action %q, must be one of %s", action, strings.Join(keyActions, ", ")))
+			continue
+		}
+		if len(keys) == 0 {
+			warnings = append(warnings, fmt.Sprintf("keys.%s: no keys given, using %s", action, strings.Join(defaultKeys[action], ", ")))
+			continue
+		}
+		valid := true
+		for index, key := range keys {
+			if key == "space" {
+				keys[index] = " "
+			} else if !validKey(key) {
+				warnings = append(warnings, fmt.Sprintf("keys.%s: invalid key %q, using %s", action, key, strings.Join(defaultKeys[action], ", ")))
+				valid = false
+			}
+		}
+		if valid {
+			checked[action] = keys
+		}
+	}
+
+	// Rebinding may free a default key for another action, so conflicts
+	// are looked for once every binding is applied. A binding that
+	// conflicts goes back to its default, which may uncover another.
+	for {
+		action, key, ok := keyConflict(keysFor(checked), checked)
+		if !ok {
+			return checked, warnings
+		}
+		warnings = append(warnings, fmt.Sprintf("keys.%s: %q is already bound, using %s", action, keyName(key), strings.Join(defaultKeys[action], ", ")))
+		delete(checked, action)
+	}
+}
+
+// keyConflict returns a rebound action whose key is fixed or also bound to
+// another action. A default binding keeps its key, and of two rebound
+// actions the first in keyActions does.
+func keyConflict(keys keyMap, rebound map[string]keyList) (string, string, bool) {
+	for index, action := range keyActions {
+		if _, ok := rebound[action]; !ok {
+			continue
+		}
+		for _, key := range keys[action] {
+			if contains(fixedKeys, key) {
+				return action, key, true
+			}
+			for otherIndex, other := range keyActions {
+				if other == action || !contains(keys[other], key) {
+					continue
+				}
+				if _, ok := rebound[other]; ok && otherIndex > index {
+					return other, key, true
+				}
+				return action, key, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// keysFor returns the keys of every action, the defaults with bindings
+// from the config applied
+func keysFor(bindings map[string]keyList) keyMap {
+	keys := make(keyMap, len(defaultKeys))
+	for action, defaults := range defaultKeys {
+		keys[action] = defaults
+		if bound, ok := bindings[action]; ok {
+			keys[action] = bound
+		}
+	}
+	return keys
+}
+
+// action returns the action bound to key, or "" when there is none
+func (k keyMap) action(key string) string {
+	for _, action := range keyActions {
+		if contains(k[action], key) {
+			return action
+		}
+	}
+	return ""
+}
+
+// help names the keys of action for the help line, like "/ or ctrl+f"
+func (k keyMap) help(action string) string {
+	names := make([]string, len(k[action]))
+	for index, key := range k[action] {
+		names[index] = keyName(key)
+	}
+	return strings.Join(names, " or ")
+}
+
+// first names the first key of action, for help with room for one
+func (k keyMap) first(action string) string {
+	if len(k[action]) == 0 {
+		return ""
+	}
+	return keyName(k[action][0])
+}
+
+// applyTo binds the list's own keys, whose help it shows, to the actions:
+// it moves with the up and down keys, and leaves filter and quit to rx
+func (k keyMap) applyTo(keyMap *list.KeyMap) {
+	keyMap.CursorUp.SetKeys(append([]string{"up"}, k["up"]...)...)
+	keyMap.CursorUp.SetHelp("↑/"+k.first("up"), "up")
+	keyMap.CursorDown.SetKeys(append([]string{"down"}, k["down"]...)...)
+	keyMap.CursorDown.SetHelp("↓/"+k.first("down"), "down")
+	keyMap.Filter.SetKeys(k["filter"]...)
+	keyMap.Filter.SetHelp(k.first("filter"), "filter")
+	keyMap.Quit.SetKeys(k["quit"]...)
+	keyMap.Quit.SetHelp(k.first("quit"), "quit")
+}
+
+// keyName shows a key in help, space by name
+func keyName(key string) string {
+	if key == " " {
+		return "space"
+	}
+	return key
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]keyList) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,58 @@
+//go:build !unix
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// staleLockAge is how old a lock file has to be before it's taken to be
+// left behind by an rx that crashed
+const staleLockAge = 5 * time.Second
+
+// lockState takes a lock on the state file at path by creating a .lock
+// file next to it, waiting while rx in another terminal holds it
+func lockState(path string) (func(), error) {
+	lockPath := path + ".lock"
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			removeStaleLock(lockPath)
+			continue
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// removeStaleLock removes the lock file at lockPath if it's still stale,
+// holding a .break file while it checks. Without it, a waiter that saw the
+// old lock could remove the one another waiter has just created in its
+// place, letting both in.
+func removeStaleLock(lockPath string) {
+	breakPath := lockPath + ".break"
+	f, err := os.OpenFile(breakPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		// Another waiter is removing it, unless it crashed doing so
+		if info, err := os.Stat(breakPath); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(breakPath)
+		}
+		time.Sleep(10 * time.Millisecond)
+		return
+	}
+	f.Close()
+	defer os.Remove(breakPath)
+
+	if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLockAge {
+		os.Remove(lockPath)
+	}
+}
@@ -1,50 +1,210 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
-	"syscall"
+	"time"
 
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
 )
 
-// Styles
+// version is set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
+// Styles, the colored ones are set from the theme by applyTheme
 var (
-	docStyle = lipgloss.NewStyle().Margin(1, 2)
-	titleStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#61AFEF")).
-		Bold(true).
-		MarginLeft(2)
-	errorStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#E06C75")).
-		Bold(true)
-	successStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#98C379")).
-		Bold(true)
+	docStyle         = lipgloss.NewStyle().Margin(1, 2)
+	titleStyle       lipgloss.Style
+	errorStyle       lipgloss.Style
+	successStyle     lipgloss.Style
+	previewStyle     lipgloss.Style
+	helpStyle        lipgloss.Style
+	filterStyle      lipgloss.Style
+	errorBannerStyle lipgloss.Style
+	paneStyle        lipgloss.Style
 )
 
 // ScriptSource represents a source of scripts (package.json or Makefile)
 type ScriptSource interface {
 	Name() string
 	GetScripts() ([]list.Item, error)
-	RunScript(name string) error
+	// ResolveCommand returns the path of the executable and the argv that
+	// RunScript would exec for name, without running anything
+	ResolveCommand(name string, extraArgs []string) (string, []string, error)
+	// RunScript runs name with env, KEY=VALUE pairs that override rx's
+	// environment
+	RunScript(name string, extraArgs, env []string) error
 }
 
 // NPMScriptSource handles scripts from package.json
 type NPMScriptSource struct {
 	PackageName    string
 	PackageVersion string
+	Manager        string // "npm", "yarn" or "pnpm"
+
+	// SynthesizeCommands offers install, test and start when package.json
+	// has no scripts but the project looks like they would work
+	SynthesizeCommands bool
+
+	// ShowHooks lists pre<name> and post<name> scripts even when <name>
+	// exists, so npm runs them around it anyway
+	ShowHooks bool
+
+	// Fallback runs scripts with npm when the detected package manager
+	// isn't installed, instead of failing
+	Fallback bool
+
+	// NodeVersion is the node version pinned by NodeVersionFile, an .nvmrc
+	// or .node-version, checked before running a script
+	NodeVersion     string
+	NodeVersionFile string
+
+	// workspaceScripts maps the "<package>/<script>" item names of
+	// workspace scripts to the package and script they run
+	workspaceScripts map[string]workspaceScript
+	// builtins are synthesized package manager commands, run without "run"
+	builtins map[string]bool
+}
+
+// hiddenHooks returns the scripts of names to leave out of the list, the
+// pre and post hooks npm runs around another script, unless ShowHooks is set
+func (n *NPMScriptSource) hiddenHooks(names []string) map[string]bool {
+	if n.ShowHooks {
+		return nil
+	}
+	return npmHooks(names)
+}
+
+// npmHooks returns the scripts of names that are pre<name> or post<name>
+// hooks of another script in names. A hook without its script, like a
+// predeploy without deploy, isn't one.
+func npmHooks(names []string) map[string]bool {
+	exists := make(map[string]bool, len(names))
+	for _, name := range names {
+		exists[name] = true
+	}
+
+	hooks := make(map[string]bool)
+	for _, name := range names {
+		for _, prefix := range []string{"pre", "post"} {
+			if base, ok := strings.CutPrefix(name, prefix); ok && base != "" && exists[base] {
+				hooks[name] = true
+			}
+		}
+	}
+	return hooks
+}
+
+// scriptList is the scripts object of a package.json. Decoding it into a
+// map would lose the order the scripts are declared in, so the keys are
+// read one token at a time instead.
+type scriptList struct {
+	Names    []string
+	Commands map[string]string
+}
+
+func (s *scriptList) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return &scriptsError{Found: jsonKind(data)}
+	}
+
+	s.Names = []string{}
+	s.Commands = make(map[string]string)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name := tok.(string) // object keys are always strings
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		var cmd string
+		if err := json.Unmarshal(raw, &cmd); err != nil {
+			return &scriptsError{Script: name, Found: jsonKind(raw)}
+		}
+
+		// Like a map, a repeated key keeps the last value
+		if _, ok := s.Commands[name]; !ok {
+			s.Names = append(s.Names, name)
+		}
+		s.Commands[name] = cmd
+	}
+
+	// Consume the closing }
+	_, err = dec.Token()
+	return err
+}
+
+// scriptDescription returns the description of an npm script from the
+// scripts-info or scripts-docs objects of the npm-scripts-info convention,
+// falling back to the script's command
+func scriptDescription(name, cmd string, docs ...map[string]any) string {
+	for _, doc := range docs {
+		if description, ok := doc[name].(string); ok && strings.TrimSpace(description) != "" {
+			return strings.TrimSpace(description)
+		}
+	}
+	return cmd
+}
+
+// Errors returned by NPMScriptSource.GetScripts when there is nothing to run
+var (
+	errNoScriptsSection = errors.New(`package.json has no "scripts" section`)
+	errEmptyScripts     = errors.New(`the "scripts" section in package.json is empty`)
+)
+
+// lockfiles maps lockfile names to the package manager that owns them,
+// in the order they are checked
+var lockfiles = []struct {
+	file    string
+	manager string
+}{
+	{"yarn.lock", "yarn"},
+	{"pnpm-lock.yaml", "pnpm"},
+	{"package-lock.json", "npm"},
+}
+
+// detectPackageManager picks the package manager based on the lockfile
+// present in the current directory, falling back to npm
+func detectPackageManager() string {
+	for _, lf := range lockfiles {
+		if _, err := os.Stat(lf.file); err == nil {
+			return lf.manager
+		}
+	}
+	return "npm"
 }
 
 func (n *NPMScriptSource) Name() string {
+	if n.NodeVersion != "" {
+		return fmt.Sprintf("%s@%s (node %s)", n.PackageName, n.PackageVersion, n.NodeVersion)
+	}
 	return fmt.Sprintf("%s@%s", n.PackageName, n.PackageVersion)
 }
 
@@ -57,48 +217,206 @@ func (n *NPMScriptSource) GetScripts() ([]list.Item, error) {
 
 	// Parse package.json
 	var packageJSON struct {
-		Name    string            `json:"name"`
-		Version string            `json:"version"`
-		Scripts map[string]string `json:"scripts"`
+		Name            string            `json:"name"`
+		Version         string            `json:"version"`
+		Scripts         *scriptList       `json:"scripts"`
+		ScriptsInfo     map[string]any    `json:"scripts-info"`
+		ScriptsDocs     map[string]any    `json:"scripts-docs"`
+		Workspaces      json.RawMessage   `json:"workspaces"`
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
 	}
 
 	if err := json.Unmarshal(data, &packageJSON); err != nil {
-		return nil, fmt.Errorf("error parsing package.json: %w", err)
-	}
-
-	if len(packageJSON.Scripts) == 0 {
-		return nil, fmt.Errorf("no scripts found in package.json")
+		return nil, packageJSONError("package.json", data, err)
 	}
 
 	// Set package name and version
 	n.PackageName = packageJSON.Name
 	n.PackageVersion = packageJSON.Version
 
-	// Create items for the list
+	// Create items for the list in the order the scripts are declared
 	items := []list.Item{}
-	for name, cmd := range packageJSON.Scripts {
-		items = append(items, item{name: name, description: cmd, source: "npm"})
+	if packageJSON.Scripts != nil {
+		hooks := n.hiddenHooks(packageJSON.Scripts.Names)
+		for _, name := range packageJSON.Scripts.Names {
+			if hooks[name] {
+				continue
+			}
+			cmd := packageJSON.Scripts.Commands[name]
+			description := scriptDescription(name, cmd, packageJSON.ScriptsInfo, packageJSON.ScriptsDocs)
+			items = append(items, item{name: name, description: description, command: cmd, source: "npm"})
+		}
 	}
 
-	return items, nil
+	// Add the scripts of workspace packages as "<package>/<script>"
+	patterns, err := workspacePatterns(packageJSON.Workspaces)
+	if err != nil {
+		return nil, fmt.Errorf("error reading workspaces: %w", err)
+	}
+	if len(patterns) > 0 {
+		workspaces, err := findWorkspaces(patterns)
+		if err != nil {
+			return nil, fmt.Errorf("error reading workspaces: %w", err)
+		}
+		n.workspaceScripts = make(map[string]workspaceScript)
+		for _, ws := range workspaces {
+			hooks := n.hiddenHooks(ws.Scripts.Names)
+			for _, script := range ws.Scripts.Names {
+				if hooks[script] {
+					continue
+				}
+				cmd := ws.Scripts.Commands[script]
+				name := ws.Name + "/" + script
+				n.workspaceScripts[name] = workspaceScript{Workspace: ws.Name, Script: script, Dir: ws.Dir}
+				description := scriptDescription(script, cmd, ws.ScriptsInfo, ws.ScriptsDocs)
+				items = append(items, item{name: name, description: description, command: cmd, source: "npm"})
+			}
+		}
+	}
+
+	if len(items) > 0 {
+		assignGroups(items, n.workspaceScripts)
+		return items, nil
+	}
+
+	// Without scripts, optionally offer the package manager's built-in
+	// commands when the project looks like they would work
+	hasDependencies := len(packageJSON.Dependencies) > 0 || len(packageJSON.DevDependencies) > 0
+	if n.SynthesizeCommands {
+		items = n.synthesizeCommands(hasDependencies)
+		if len(items) > 0 {
+			return items, nil
+		}
+	}
+
+	// A nil list means the field is missing, an empty one that it's {}
+	err = errEmptyScripts
+	if packageJSON.Scripts == nil {
+		err = errNoScriptsSection
+	}
+	if hasDependencies && !n.SynthesizeCommands {
+		return nil, fmt.Errorf("%w (use --npm-defaults to offer install, test and start)", err)
+	}
+	return nil, err
+}
+
+// synthesizeCommands creates items for the package manager's built-in
+// install, test and start commands when files in the project indicate they
+// would work
+func (n *NPMScriptSource) synthesizeCommands(hasDependencies bool) []list.Item {
+	manager := n.PackageManager()
+	exists := func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+
+	candidates := []struct {
+		name        string
+		description string
+		available   bool
+	}{
+		{"install", "install dependencies", hasDependencies},
+		{"test", "run tests", exists("test") || exists("tests") || exists("__tests__")},
+		// npm start defaults to node server.js
+		{"start", "node server.js", exists("server.js")},
+	}
+
+	n.builtins = make(map[string]bool)
+	items := []list.Item{}
+	for _, candidate := range candidates {
+		if !candidate.available {
+			continue
+		}
+		n.builtins[candidate.name] = true
+		items = append(items, item{
+			name:        candidate.name,
+			description: candidate.description + " (no script defined)",
+			command:     manager + " " + candidate.name,
+			source:      "npm",
+		})
+	}
+	return items
+}
+
+// PackageManager returns the package manager used to run scripts
+func (n *NPMScriptSource) PackageManager() string {
+	if n.Manager == "" {
+		n.Manager = detectPackageManager()
+	}
+	return n.Manager
 }
 
-func (n *NPMScriptSource) RunScript(name string) error {
-	// Find the path to npm executable
-	npmPath, err := exec.LookPath("npm")
+func (n *NPMScriptSource) ResolveCommand(name string, extraArgs []string) (string, []string, error) {
+	manager := n.PackageManager()
+
+	// Find the path to the package manager executable, or npm's with
+	// --pm-fallback
+	pmPath, err := exec.LookPath(manager)
+	if err != nil && manager != "npm" {
+		fallback := joinArgs(n.runArgs("npm", name, extraArgs))
+		if !n.Fallback {
+			return "", nil, fmt.Errorf("%s not found (detected from lockfile), install it or run with --pm-fallback to run %s instead: %w", manager, fallback, err)
+		}
+		manager = "npm"
+		pmPath, err = exec.LookPath(manager)
+	}
 	if err != nil {
-		return fmt.Errorf("npm not found: %w", err)
+		return "", nil, fmt.Errorf("%s not found (detected from lockfile), install it or remove the lockfile: %w", manager, err)
+	}
+
+	return pmPath, n.runArgs(manager, name, extraArgs), nil
+}
+
+// CommandArgs returns the command line running name with the package
+// manager of the lockfile, without looking for it or falling back to npm
+func (n *NPMScriptSource) CommandArgs(name string, extraArgs []string) ([]string, error) {
+	return n.runArgs(n.PackageManager(), name, extraArgs), nil
+}
+
+// runArgs returns the command line running the script name with manager
+func (n *NPMScriptSource) runArgs(manager, name string, extraArgs []string) []string {
+	// Prepare arguments for <manager> run, extra args go after "--"
+	args := []string{manager, "run", name}
+	if ref, ok := n.workspaceScripts[name]; ok {
+		args = workspaceRunArgs(manager, ref)
+	} else if n.builtins[name] {
+		args = []string{manager, name}
+	}
+	if len(extraArgs) > 0 {
+		args = append(args, "--")
+		args = append(args, extraArgs...)
 	}
+	return args
+}
+
+// warnFallback warns that npm runs the script instead of the package
+// manager of the lockfile, when --pm-fallback fell back to it
+func (n *NPMScriptSource) warnFallback(args []string) {
+	if manager := n.PackageManager(); args[0] != manager {
+		fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Warning: %s not found, running %s instead", manager, joinArgs(args))))
+	}
+}
 
-	// Prepare arguments for npm run
-	args := []string{"npm", "run", name}
+func (n *NPMScriptSource) RunScript(name string, extraArgs, env []string) error {
+	path, args, err := n.ResolveCommand(name, extraArgs)
+	if err != nil {
+		return err
+	}
+	n.warnFallback(args)
+	n.checkNodeVersion("")
 
-	// Replace the current process with npm run
-	return syscall.Exec(npmPath, args, os.Environ())
+	// Replace the current process with the command
+	return runExec(path, args, env)
 }
 
 // MakefileScriptSource handles targets from Makefile
-type MakefileScriptSource struct{}
+type MakefileScriptSource struct {
+	NoCache bool          // always run make -pn instead of using the cache
+	Timeout time.Duration // limit for make -pn, 0 for none
+	// IncludeInternal lists internal targets too, see parseMakefileTargets
+	IncludeInternal bool
+}
 
 func (m *MakefileScriptSource) Name() string {
 	return "Makefile"
@@ -110,460 +428,3152 @@ func (m *MakefileScriptSource) GetScripts() ([]list.Item, error) {
 		return nil, fmt.Errorf("Makefile not found")
 	}
 
-	// Run make -pn to get all targets
-	cmd := exec.Command("make", "-pn")
-	output, err := cmd.Output()
+	targets, recipes, err := m.targets()
 	if err != nil {
-		return nil, fmt.Errorf("error running make -pn: %w", err)
+		return nil, err
 	}
-
-	// Parse the output to find targets
-	targets := parseMakefileTargets(string(output))
 	if len(targets) == 0 {
 		return nil, fmt.Errorf("no targets found in Makefile")
 	}
 
+	// Descriptions come from ## comments, a missing one isn't an error
+	descriptions := map[string]string{}
+	if data, err := os.ReadFile("Makefile"); err == nil {
+		descriptions = parseMakefileDescriptions(string(data))
+	}
+
 	// Create items for the list
 	items := []list.Item{}
 	for _, target := range targets {
-		items = append(items, item{name: target, description: "make target", source: "make"})
+		description := descriptions[target]
+		if description == "" {
+			description = "make target"
+		}
+		items = append(items, item{name: target, description: description, command: recipes[target], source: "make"})
 	}
 
 	return items, nil
 }
 
-func (m *MakefileScriptSource) RunScript(name string) error {
+// targets returns the targets of the Makefile and their recipes, from the
+// cache when the Makefile hasn't changed since make -pn last ran. Changes
+// to included makefiles aren't noticed, --no-cache bypasses the cache. The
+// cache holds the usual targets, so internal ones are always read from make.
+func (m *MakefileScriptSource) targets() ([]string, map[string]string, error) {
+	path, err := filepath.Abs("Makefile")
+	if err != nil {
+		return nil, nil, err
+	}
+	if !m.NoCache && !m.IncludeInternal {
+		if entry, ok := loadMakeCache(path); ok {
+			return entry.Targets, entry.Recipes, nil
+		}
+	}
+
+	// Run make -pn to get all targets
+	output, err := listOutput(m.Timeout, "make", "-pn")
+	if err != nil {
+		return nil, nil, fmt.Errorf("error running make -pn: %w", err)
+	}
+
+	// Parse the output to find targets and their recipes
+	targets := parseMakefileTargets(string(output), m.IncludeInternal)
+	recipes := parseMakefileRecipes(string(output))
+
+	// A failed write only costs the next launch a make -pn
+	if len(targets) > 0 && !m.IncludeInternal {
+		saveMakeCache(path, targets, recipes)
+	}
+	return targets, recipes, nil
+}
+
+// CommandArgs returns the make command line running name, without
+// looking for make
+func (m *MakefileScriptSource) CommandArgs(name string, extraArgs []string) ([]string, error) {
+	// Prepare arguments for make, extra args are appended directly
+	args := []string{"make", name}
+	args = append(args, extraArgs...)
+
+	return args, nil
+}
+
+func (m *MakefileScriptSource) ResolveCommand(name string, extraArgs []string) (string, []string, error) {
 	// Find the path to make executable
 	makePath, err := exec.LookPath("make")
 	if err != nil {
-		return fmt.Errorf("make not found: %w", err)
+		return "", nil, fmt.Errorf("make not found: %w", err)
 	}
 
-	// Prepare arguments for make
-	args := []string{"make", name}
+	args, err := m.CommandArgs(name, extraArgs)
+	if err != nil {
+		return "", nil, err
+	}
+	return makePath, args, nil
+}
+
+func (m *MakefileScriptSource) RunScript(name string, extraArgs, env []string) error {
+	path, args, err := m.ResolveCommand(name, extraArgs)
+	if err != nil {
+		return err
+	}
 
-	// Replace the current process with make
-	return syscall.Exec(makePath, args, os.Environ())
+	// Replace the current process with the command
+	return runExec(path, args, env)
 }
 
-// parseMakefileTargets extracts targets from make -pn output
-func parseMakefileTargets(output string) []string {
+// isMakeAssignment reports whether a makefile line assigns a variable with
+// =, :=, ::=, :::=, ?=, += or !=, rather than being a rule. The line is an
+// assignment when no colon comes before the operator, so a target-specific
+// variable like "test: VERBOSE=1" is a rule of test.
+func isMakeAssignment(line string) bool {
+	eq := strings.Index(line, "=")
+	if eq < 0 {
+		return false
+	}
+	name := line[:eq]
+	if strings.HasSuffix(name, "?") || strings.HasSuffix(name, "+") || strings.HasSuffix(name, "!") {
+		name = name[:len(name)-1]
+	} else {
+		name = strings.TrimRight(name, ":")
+	}
+	return !strings.Contains(name, ":")
+}
+
+// parseMakefileTargets extracts targets from make -pn output. Targets
+// declared .PHONY are the canonical runnable targets; when there are none,
+// every target in the database is listed instead. Internal targets, those
+// isSpecialTarget matches, are left out unless includeInternal is set, which
+// also lists every target rather than only the .PHONY ones.
+func parseMakefileTargets(output string, includeInternal bool) []string {
+	if phony := parseMakefilePhony(output); len(phony) > 0 && !includeInternal {
+		result := []string{}
+		for target := range phony {
+			if !isSpecialTarget(target) {
+				result = append(result, target)
+			}
+		}
+		sort.Strings(result)
+		return result
+	}
+
 	lines := strings.Split(output, "\n")
 	targets := make(map[string]bool)
 
+	// Targets are listed in the "# Files" section of the database, anything
+	// before it is the dry-run output of the default goal or make's variables
+	for i, line := range lines {
+		if line == "# Files" {
+			lines = lines[i+1:]
+			break
+		}
+	}
+
+	notATarget := false
 	for _, line := range lines {
-		// Look for lines that define targets (end with :)
-		if strings.Contains(line, ":") && !strings.Contains(line, "=") {
+		// The database ends with hash-table stats
+		if strings.HasPrefix(line, "# files hash-table stats") {
+			break
+		}
+		// make marks files it only knows as prerequisites
+		if strings.HasPrefix(line, "# Not a target:") {
+			notATarget = true
+			continue
+		}
+		// Skip blank lines, comments and indented recipe lines
+		if line == "" || strings.HasPrefix(line, "#") ||
+			strings.HasPrefix(line, "\t") || strings.HasPrefix(line, " ") {
+			continue
+		}
+		skip := notATarget
+		notATarget = false
+		if skip {
+			continue
+		}
+
+		// Look for lines that define targets, skipping variable assignments.
+		// A rule can name several targets before the colon.
+		if strings.Contains(line, ":") && !isMakeAssignment(line) {
 			parts := strings.Split(line, ":")
-			if len(parts) > 0 {
-				target := strings.TrimSpace(parts[0])
-				// Filter out targets that start with . or have special characters
-				if target != "" && !strings.HasPrefix(target, ".") && 
-				   !strings.Contains(target, "#") && !strings.Contains(target, "%") &&
-				   !strings.Contains(target, "/") {
+			for _, target := range strings.Fields(parts[0]) {
+				if includeInternal || !isSpecialTarget(target) {
 					targets[target] = true
 				}
 			}
 		}
 	}
 
-	// Convert map to slice
+	// Convert map to slice, sorted so the order is stable between runs
 	result := []string{}
 	for target := range targets {
 		result = append(result, target)
 	}
+	sort.Strings(result)
 
 	return result
 }
 
+// parseMakefilePhony collects the prerequisites of every .PHONY line
+func parseMakefilePhony(output string) map[string]bool {
+	phony := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.HasPrefix(line, ".PHONY:") {
+			continue
+		}
+		for _, target := range strings.Fields(strings.TrimPrefix(line, ".PHONY:")) {
+			phony[target] = true
+		}
+	}
+	return phony
+}
+
+// isSpecialTarget reports whether a target is internal to make rather than
+// something to run: special targets like .PHONY and .DEFAULT, suffix rules
+// like .c.o, and pattern rules like %.o. Slashes and dots elsewhere in the
+// name are fine, e.g. build/linux or docs.html.
+func isSpecialTarget(target string) bool {
+	return strings.HasPrefix(target, ".") || strings.Contains(target, "%")
+}
+
+// splitArgs splits a command line into arguments, honoring single quotes,
+// double quotes and backslash escapes the way a shell would
+func splitArgs(input string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, r := range input {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash in arguments")
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in arguments", quote)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
+
+// shellQuote quotes an argument so it can be pasted into a shell
+func shellQuote(arg string) string {
+	if arg == "" {
+		return "''"
+	}
+	if !strings.ContainsAny(arg, " \t\n'\"\\$`|&;<>()*?[]{}!#~") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// joinArgs quotes and joins arguments for display
+func joinArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// parseMakefileRecipes extracts the recipe body of each target from the
+// make -pn database output, keyed by target name
+func parseMakefileRecipes(output string) map[string]string {
+	recipes := make(map[string]string)
+	current := ""
+
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case line == "":
+			// Database entries are separated by blank lines
+			current = ""
+		case strings.HasPrefix(line, "\t"):
+			// Recipe lines are tab-indented below their target
+			if current != "" {
+				recipe := strings.TrimPrefix(line, "\t")
+				if recipes[current] != "" {
+					recipe = recipes[current] + "\n" + recipe
+				}
+				recipes[current] = recipe
+			}
+		case strings.HasPrefix(line, "#"):
+			// Comments between a target and its recipe
+		case strings.Contains(line, ":") && !strings.Contains(line, "="):
+			current = strings.TrimSpace(strings.Split(line, ":")[0])
+		default:
+			current = ""
+		}
+	}
+
+	return recipes
+}
+
+// parseMakefileDescriptions reads the descriptions of targets from the
+// Makefile itself, written as in make help conventions: after ## on the
+// rule line, or in ## comments right above it. The inline one wins.
+func parseMakefileDescriptions(data string) map[string]string {
+	descriptions := make(map[string]string)
+	comment := []string{}
+
+	for _, line := range strings.Split(data, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "##") {
+			comment = append(comment, strings.TrimSpace(strings.TrimLeft(trimmed, "#")))
+			continue
+		}
+		above := strings.Join(comment, " ")
+		comment = comment[:0]
+		if strings.HasPrefix(line, "\t") || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		rule, inline, _ := strings.Cut(line, "##")
+		targets, _, ok := strings.Cut(rule, ":")
+		if !ok || isMakeAssignment(rule) {
+			continue
+		}
+		description := strings.TrimSpace(inline)
+		if description == "" {
+			description = above
+		}
+		if description == "" {
+			continue
+		}
+		for _, target := range strings.Fields(targets) {
+			if _, ok := descriptions[target]; !ok {
+				descriptions[target] = description
+			}
+		}
+	}
+
+	return descriptions
+}
+
 type item struct {
 	name        string
 	description string
-	source      string // "npm" or "make"
+	command     string // resolved command or recipe, shown in the preview
+	source      string // "npm", "make", "just", "task", "composer", ...
+	matches     []int  // rune indices of name matched by the filter
+	favorite    bool   // starred, listed before everything else
+	queued      int    // position in the run queue, 0 when not queued
+	duplicate   bool   // another source has a script with the same name
+	group       string // namespace within the source, like test for test:unit
+}
+
+// Title tells apart scripts of the same name with their source
+func (i item) Title() string {
+	if i.duplicate {
+		return i.name + " (" + i.source + ")"
+	}
+	return i.name
 }
 
-func (i item) Title() string       { return i.name }
 func (i item) Description() string { return i.description }
 func (i item) FilterValue() string { return i.name }
 
 type model struct {
-	list           list.Model
-	selected       string
-	quitting       bool
-	error          string
-	filterInput    string
-	allItems       []list.Item
-	filterFocused  bool
-	form           *huh.Form
-	source         ScriptSource
+	list          list.Model
+	selected      []item // scripts to run once rx quits, in order
+	queue         []item // scripts marked with space, run in order by enter
+	quitting      bool
+	signal        os.Signal // SIGINT or SIGTERM quit rx, which exits like it was killed by it
+	error         string    // shown in a banner above the list until a key is pressed
+	filterInput   string
+	allItems      []list.Item
+	filterFocused bool
+	matchDesc     bool // the filter also matches descriptions
+	matched       int  // scripts matching the filter, all of them without one
+	restored      bool // the filter was restored by remember_filter, not yet announced
+	dryRun        bool // print the selected command instead of running it
+	autoRun       bool // run the only script the filter matches
+	printResult   bool // main prints the selected scripts as JSON
+	parallel      bool // run queued scripts at the same time
+	status        string
+	statusErr     bool
+	quitPending   bool            // q was pressed once while a filter is active
+	keys          keyMap          // keys of the actions the config can rebind
+	dir           string          // working directory, favorites are per directory
+	favorites     map[string]bool // keys from favoriteKey
+	collapsed     map[string]bool // collapsed groups, keys from groupKey
+	form          *huh.Form
+	filterField   *huh.Input
+	sources       map[string]ScriptSource
+	extraArgs     []string
+	argsFocused   bool
+	argsForm      *huh.Form
+	argsField     *huh.Input
+	argsError     string
+	env           []string                     // KEY=VALUE pairs from the environment prompt
+	envDefaults   map[string]map[string]string // from the env table of the config
+	envFocused    bool
+	envForm       *huh.Form
+	envField      *huh.Input
+	envError      string
+	flagDefaults  map[string][]string // from the flags table of the config
+	flagsFocused  bool
+	flagsForm     *huh.Form
+	flagsField    *huh.MultiSelect[string]
+	width         int
+	height        int
+	paneWidth     int // width of the preview pane, 0 when it's hidden
+
+	// Where the highlighted script is defined, for the preview pane. Finding
+	// it reads the source's file, so it's kept until the highlight moves or
+	// the scripts are reloaded.
+	location    string
+	locationKey string // source and name of the script location is for
+
+	// v shows the whole definition of a script in a pager
+	viewing     bool
+	viewingItem item
+	viewingHelp bool // the pager shows the help instead of viewingItem
+	pager       viewport.Model
+
+	// Confirmation for destructive scripts
+	confirmPatterns []string
+	confirmAll      bool                     // ask before every run, showing what it runs
+	dotenv          []string                 // from .env files, for the summary of confirmAll and the run
+	readDotenv      func() ([]string, error) // reads dotenv again after moving to the parent
+	confirming      bool
+	confirmForm     *huh.Form
+	confirmField    *huh.Confirm
+	pending         []item
+	pendingArgs     []string
+
+	// Scripts are enumerated in the background while a spinner is shown
+	loading        bool
+	load           func(dir string) tea.Cmd
+	spinner        spinner.Model
+	loadErr        error
+	warnings       []error
+	reselect       item   // selected before reloading, selected again after
+	announceReload bool   // r was pressed, say so once the scripts are loaded
+	root           string // project root found above the starting directory
+
+	// --profile times the start of the TUI, nil without it
+	profile *profiler
+	started time.Time
+
+	// Without scripts, a screen offers ways to get some
+	noSource      bool
+	noSourceErr   error
+	noSourceForm  *huh.Form
+	noSourceField *huh.Select[string]
+
+	// Clicks are mapped to items by their height in the list
+	itemHeight     int
+	itemSpacing    int
+	lastClick      time.Time
+	lastClickIndex int
+}
+
+// newFilterForm creates the huh form used for the filter input. The field
+// is returned too so Update can read its value as the user types.
+func newFilterForm() (*huh.Form, *huh.Input) {
+	field := huh.NewInput().
+		Title("Filter").
+		Placeholder("Type to filter scripts...").
+		Key("filter")
+	form := huh.NewForm(huh.NewGroup(field)).WithShowHelp(false).WithShowErrors(false)
+	return form, field
 }
 
 func (m model) Init() tea.Cmd {
+	m.profile.track("tui init", m.started)
+
 	// Initialize the form if it's nil
 	if m.form == nil {
-		m.form = huh.NewForm(
-			huh.NewGroup(
-				huh.NewInput().
-					Title("Filter").
-					Placeholder("Type to filter scripts...").
-					Value(&m.filterInput).
-					Validate(func(s string) error {
-						m.filterInput = s
-						m.applyFilter()
-						return nil // Always valid
-					}).
-					Key("filter"),
-			),
-		).WithShowHelp(false).WithShowErrors(false)
-	}
-
-	// Initialize the form
+		m.form, m.filterField = newFilterForm()
+	}
+
+	// Initialize the form, and enumerate the scripts if they aren't known yet
+	if m.loading {
+		return tea.Batch(m.form.Init(), m.spinner.Tick, m.load(m.dir))
+	}
 	return m.form.Init()
 }
 
-// applyFilter filters the list items based on the filter input
+// scriptsLoadedMsg carries the result of enumerating the script sources
+type scriptsLoadedMsg struct {
+	sources   map[string]ScriptSource
+	items     []list.Item
+	favorites map[string]bool
+	warnings  []error
+	err       error
+}
+
+// loadScripts enumerates the script sources in the background, so slow
+// sources like make -pn don't leave rx looking frozen. Items are sorted in
+// order and marked with the favorites of dir.
+func loadScripts(opts sourceOptions, order, dir string) tea.Cmd {
+	return func() tea.Msg {
+		var msg scriptsLoadedMsg
+
+		// Warnings can't be printed while the TUI owns the terminal
+		opts.Warn = func(err error) {
+			msg.warnings = append(msg.warnings, err)
+		}
+		msg.sources, msg.items, msg.err = findScriptSources(opts)
+		if msg.err != nil {
+			return msg
+		}
+		start := time.Now()
+		msg.items = sortItems(msg.items, order, dir)
+
+		// Starred scripts are listed first
+		favorites, err := loadFavorites(dir)
+		if err != nil {
+			msg.warnings = append(msg.warnings, fmt.Errorf("could not load favorites: %w", err))
+			favorites = make(map[string]bool)
+		}
+		markFavorites(msg.items, favorites)
+		msg.favorites = favorites
+		opts.Profile.track("sort", start)
+
+		return msg
+	}
+}
+
+// descriptionPenalty is subtracted from the score of items whose
+// description matches the filter but whose name doesn't, so any name match
+// ranks above them
+const descriptionPenalty = 10000
+
+// applyFilter filters the list items based on the filter input and ranks
+// them by fuzzy match score so the best match is selected first
 func (m *model) applyFilter() {
 	if m.filterInput == "" {
 		// If filter is empty, show all items
-		m.list.SetItems(m.allItems)
+		m.matched = countItems(m.allItems)
+		m.list.SetItems(groupBySource(m.allItems, m.collapsed))
+		m.skipHeader(true)
 		return
 	}
 
-	// Filter items based on the filter input
-	filtered := []list.Item{}
-	for _, item := range m.allItems {
-		if strings.Contains(strings.ToLower(item.FilterValue()), strings.ToLower(m.filterInput)) {
-			filtered = append(filtered, item)
+	// Score items against the filter input
+	type scored struct {
+		item  list.Item
+		score int
+	}
+	matches := []scored{}
+	for _, listItem := range m.allItems {
+		score, positions, ok := fuzzyMatch(m.filterInput, listItem.FilterValue())
+		if !ok && m.matchDesc {
+			if i, isItem := listItem.(item); isItem {
+				score, _, ok = fuzzyMatch(m.filterInput, i.description)
+				score -= descriptionPenalty
+			}
+		}
+		if !ok {
+			continue
+		}
+		// Record the matched runes so the delegate can highlight them
+		if i, isItem := listItem.(item); isItem {
+			i.matches = positions
+			listItem = i
 		}
+		matches = append(matches, scored{item: listItem, score: score})
+	}
+
+	// Best match first, ties keep their original order
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	filtered := make([]list.Item, len(matches))
+	for i, match := range matches {
+		filtered[i] = match.item
 	}
+	m.matched = countItems(filtered)
 
-	m.list.SetItems(filtered)
+	// Groups are expanded to show every match
+	m.list.SetItems(groupBySource(filtered, nil))
+	m.list.Select(0)
+	m.skipHeader(true)
 }
 
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmd tea.Cmd
-	var cmds []tea.Cmd
+// singleMatch reports whether the filter narrowed the list to one script,
+// which --auto-run then runs. Scripts are never run this way while others
+// are queued.
+func (m model) singleMatch() bool {
+	return m.filterInput != "" && len(m.queue) == 0 && countItems(m.list.Items()) == 1
+}
 
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		if m.filterFocused {
-			// When filter is focused, handle special keys
-			switch msg.String() {
-			case "ctrl+c", "esc":
-				m.quitting = true
-				return m, tea.Quit
-			case "enter", "tab", "down":
-				// Move focus to the list
-				m.filterFocused = false
-				return m, nil
-			default:
-				// Handle input in the form
-				var formCmd tea.Cmd
-				formModel, formCmd := m.form.Update(msg)
-				m.form = formModel.(*huh.Form)
-				
-				// Apply filter after form update
-				m.applyFilter()
-				return m, formCmd
-			}
-		} else {
-			// When list is focused
-			switch msg.String() {
-			case "ctrl+c", "q":
-				m.quitting = true
-				return m, tea.Quit
-			case "/", "ctrl+f":
-				// Switch focus to filter
-				m.filterFocused = true
+// favoritesHeader is the title of the group of starred scripts
+const favoritesHeader = "★ favorites"
+
+// groupBySource puts items under a header for each source, keeping their
+// order within a source. Favorites come first in a group of their own, then
+// sources in the order of their first item, so the group with the best
+// filter match comes first. Within a source, grouped scripts are put under
+// a header for each group, leaving out those of collapsed groups.
+func groupBySource(items []list.Item, collapsed map[string]bool) []list.Item {
+	var order []string
+	groups := make(map[string][]list.Item)
+	var favorites []list.Item
+	for _, listItem := range items {
+		i, ok := listItem.(item)
+		if !ok {
+			continue
+		}
+		if i.favorite {
+			favorites = append(favorites, listItem)
+			continue
+		}
+		if _, seen := groups[i.source]; !seen {
+			order = append(order, i.source)
+		}
+		groups[i.source] = append(groups[i.source], listItem)
+	}
+
+	grouped := make([]list.Item, 0, len(items)+len(order)+1)
+	if len(favorites) > 0 {
+		grouped = append(grouped, header{source: favoritesHeader})
+		grouped = append(grouped, favorites...)
+	}
+	for _, source := range order {
+		grouped = append(grouped, header{source: source})
+		grouped = append(grouped, groupScripts(source, groups[source], collapsed)...)
+	}
+	return grouped
+}
+
+// skipHeader moves the selection off a header row, continuing in the
+// direction the cursor was moving or turning back at the ends of the list.
+// The header of a collapsed group can be selected to expand it.
+func (m *model) skipHeader(down bool) {
+	items := m.list.Items()
+	isHeader := func(index int) bool {
+		switch h := items[index].(type) {
+		case header:
+			return true
+		case groupHeader:
+			return !h.collapsed
+		}
+		return false
+	}
+
+	index := m.list.Index()
+	if index < 0 || index >= len(items) || !isHeader(index) {
+		return
+	}
+
+	step := 1
+	if !down {
+		step = -1
+	}
+	for _, dir := range []int{step, -step} {
+		for next := index + dir; next >= 0 && next < len(items); next += dir {
+			if !isHeader(next) {
+				m.list.Select(next)
+				return
+			}
+		}
+	}
+}
+
+// statusTimeout is how long a status message like "Copied!" is shown
+const statusTimeout = 2 * time.Second
+
+// copiedMsg reports the result of copying a command to the clipboard
+type copiedMsg struct {
+	command string
+	err     error
+}
+
+// clearStatusMsg hides the status message it was scheduled for
+type clearStatusMsg struct {
+	status string
+}
+
+// copyCommand copies the resolved command of i to the clipboard
+func (m model) copyCommand(i item) tea.Cmd {
+	source := m.sources[i.source]
+	return func() tea.Msg {
+		_, args, err := source.ResolveCommand(i.name, m.extraArgs)
+		if err != nil {
+			return copiedMsg{err: err}
+		}
+		command := joinArgs(args)
+		return copiedMsg{command: command, err: copyToClipboard(command)}
+	}
+}
+
+// toggleFavorite stars or unstars i, saves the favorites and keeps i
+// selected as it moves in or out of the favorites group
+func (m *model) toggleFavorite(i item) tea.Cmd {
+	key := favoriteKey(i.source, i.name)
+	if m.favorites[key] {
+		delete(m.favorites, key)
+	} else {
+		m.favorites[key] = true
+	}
+	markFavorites(m.allItems, m.favorites)
+	m.applyFilter()
+	m.selectItem(i.source, i.name)
+
+	if err := saveFavorites(m.dir, m.favorites); err != nil {
+		return m.setStatus("Could not save favorites: "+err.Error(), true)
+	}
+	return nil
+}
+
+// selectItem selects the item name from source if it's in the list
+func (m *model) selectItem(source, name string) {
+	for index, listItem := range m.list.Items() {
+		if i, ok := listItem.(item); ok && i.source == source && i.name == name {
+			m.list.Select(index)
+			return
+		}
+	}
+}
+
+// setStatus shows a status message below the list until statusTimeout
+// passes
+func (m *model) setStatus(status string, isErr bool) tea.Cmd {
+	m.status = status
+	m.statusErr = isErr
+	return tea.Tick(statusTimeout, func(time.Time) tea.Msg {
+		return clearStatusMsg{status: status}
+	})
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updated, cmd := m.update(msg)
+	next := updated.(model)
+	next.updateLocation()
+	return next, cmd
+}
+
+// update handles msg for Update
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.error != "" && msg.String() != "ctrl+c" {
+			// Any key dismisses the error banner
+			m.error = ""
+			return m, nil
+		}
+		if m.viewing {
+			return m.updatePager(msg)
+		}
+		if m.noSource {
+			// Without scripts, choose how to get some
+			if m.keys.action(msg.String()) == "quit" {
+				m.quitting = true
+				return m, tea.Quit
+			}
+			switch msg.String() {
+			case "ctrl+c", "esc":
+				m.quitting = true
+				return m, tea.Quit
+			case "enter":
+				// The select only stores the highlighted option on enter,
+				// the form would move on to its end and quit
+				m.noSourceField.Update(msg)
+				choice, _ := m.noSourceField.GetValue().(string)
+				m.status = ""
+				return m.chooseNoSource(choice)
+			default:
+				formModel, formCmd := m.noSourceForm.Update(msg)
+				m.noSourceForm = formModel.(*huh.Form)
+				return m, formCmd
+			}
+		} else if m.confirming {
+			// When confirming a destructive script, handle special keys
+			switch msg.String() {
+			case "ctrl+c":
+				m.quitting = true
+				return m, tea.Quit
+			case "y", "Y":
+				return m.confirmRun(true)
+			case "n", "N", "esc":
+				return m.confirmRun(false)
+			case "enter":
+				confirmed, _ := m.confirmField.GetValue().(bool)
+				return m.confirmRun(confirmed)
+			default:
+				formModel, formCmd := m.confirmForm.Update(msg)
+				m.confirmForm = formModel.(*huh.Form)
+				return m, formCmd
+			}
+		} else if m.argsFocused {
+			// When the arguments prompt is open, handle special keys
+			switch msg.String() {
+			case "ctrl+c":
+				m.quitting = true
+				return m, tea.Quit
+			case "esc":
+				// Close the prompt and return to the list
+				m.argsFocused = false
+				m.argsError = ""
+				return m, nil
+			case "enter":
+				value, _ := m.argsField.GetValue().(string)
+				args, err := splitArgs(value)
+				if err != nil {
+					m.argsError = err.Error()
+					return m, nil
+				}
+				if items := m.runTargets(); len(items) > 0 {
+					m.argsFocused = false
+					return m.runItems(items, args)
+				}
+				return m, nil
+			default:
+				formModel, formCmd := m.argsForm.Update(msg)
+				m.argsForm = formModel.(*huh.Form)
+				m.argsError = ""
+				return m, formCmd
+			}
+		} else if m.flagsFocused {
+			// When the flags prompt is open, handle special keys
+			switch msg.String() {
+			case "ctrl+c":
+				m.quitting = true
+				return m, tea.Quit
+			case "esc":
+				m.flagsFocused = false
+				return m, nil
+			case "enter":
+				// The multi-select only stores the chosen flags when it's
+				// left, the form would move on to its end and quit
+				m.flagsField.Update(msg)
+				chosen, _ := m.flagsField.GetValue().([]string)
+				return m.pickFlags(chosen)
+			default:
+				formModel, formCmd := m.flagsForm.Update(msg)
+				m.flagsForm = formModel.(*huh.Form)
+				return m, formCmd
+			}
+		} else if m.envFocused {
+			// When the environment prompt is open, handle special keys
+			switch msg.String() {
+			case "ctrl+c":
+				m.quitting = true
+				return m, tea.Quit
+			case "esc":
+				m.envFocused = false
+				m.envError = ""
 				return m, nil
 			case "enter":
-				i, ok := m.list.SelectedItem().(item)
-				if ok {
-					m.selected = i.name
-					return m, tea.Quit
+				value, _ := m.envField.GetValue().(string)
+				env, err := parseEnv(value)
+				if err != nil {
+					m.envError = err.Error()
+					return m, nil
+				}
+				if items := m.runTargets(); len(items) > 0 {
+					m.envFocused = false
+					m.env = env
+					return m.runItems(items, m.extraArgs)
+				}
+				return m, nil
+			default:
+				formModel, formCmd := m.envForm.Update(msg)
+				m.envForm = formModel.(*huh.Form)
+				m.envError = ""
+				return m, formCmd
+			}
+		} else if m.filterFocused {
+			// When filter is focused, handle special keys. Letters are
+			// always filter input, esc goes back to the list.
+			switch msg.String() {
+			case "ctrl+c":
+				m.quitting = true
+				return m, tea.Quit
+			case "enter", "esc":
+				// Move focus to the list, keeping the highlighted match.
+				// Running it takes another enter.
+				m.filterFocused = false
+				return m, nil
+			case "tab", "down":
+				// Move focus to the list and down it in the same keypress
+				m.filterFocused = false
+				m.list.CursorDown()
+				m.skipHeader(true)
+				return m, nil
+			case "ctrl+d":
+				m.matchDesc = !m.matchDesc
+				m.applyFilter()
+				return m, nil
+			case "ctrl+n", "ctrl+p":
+				// Move through the matches without leaving the filter
+				down := msg.String() == "ctrl+n"
+				if down {
+					m.list.CursorDown()
+				} else {
+					m.list.CursorUp()
+				}
+				m.skipHeader(down)
+				return m, nil
+			default:
+				// Handle input in the form
+				var formCmd tea.Cmd
+				formModel, formCmd := m.form.Update(msg)
+				m.form = formModel.(*huh.Form)
+				
+				// Apply filter after form update
+				if m.filterField != nil {
+					m.filterInput, _ = m.filterField.GetValue().(string)
+				}
+				m.applyFilter()
+				if m.autoRun && m.singleMatch() {
+					m.filterFocused = false
+					return m.runItems(m.runTargets(), m.extraArgs)
+				}
+				return m, formCmd
+			}
+		} else {
+			// When list is focused. esc clears an active filter before it
+			// quits, and q asks again while a filter is active, since it
+			// may have been meant for the filter.
+			action := m.keys.action(msg.String())
+			if action != "quit" {
+				m.quitPending = false
+			}
+
+			// Actions that can be rebound in the config's [keys]
+			switch action {
+			case "quit":
+				if m.filterInput != "" && !m.quitPending {
+					m.quitPending = true
+					return m, m.setStatus(fmt.Sprintf("A filter is active, press %s again to quit", keyName(msg.String())), false)
+				}
+				m.quitting = true
+				return m, tea.Quit
+			case "filter":
+				// Switch focus to filter
+				m.filterFocused = true
+				return m, nil
+			case "down":
+				m.list.CursorDown()
+				m.skipHeader(true)
+				return m, nil
+			case "up":
+				m.list.CursorUp()
+				m.skipHeader(false)
+				return m, nil
+			case "reload":
+				// Read the scripts again, keeping the filter and selection
+				m.reselect, _ = m.list.SelectedItem().(item)
+				m.announceReload = true
+				return m.reload()
+			case "run":
+				if _, ok := m.list.SelectedItem().(groupHeader); ok {
+					m.toggleGroup()
+					return m, nil
+				}
+				if items := m.runTargets(); len(items) > 0 {
+					return m.runItems(items, m.extraArgs)
+				}
+				return m, nil
+			}
+
+			switch msg.String() {
+			case "ctrl+c":
+				m.quitting = true
+				return m, tea.Quit
+			case "esc":
+				if m.filterInput != "" {
+					m.filterInput = ""
+					m.form, m.filterField = newFilterForm()
+					m.applyFilter()
+					return m, m.form.Init()
+				}
+				if len(m.queue) > 0 {
+					m.queue = nil
+					markQueued(m.allItems, m.queue)
+					m.applyFilter()
+					return m, nil
+				}
+				m.quitting = true
+				return m, tea.Quit
+			case "ctrl+d":
+				m.matchDesc = !m.matchDesc
+				m.applyFilter()
+				return m, nil
+			case "p":
+				m.dryRun = !m.dryRun
+				return m, nil
+			case "z":
+				// Collapse or expand the selected group
+				m.toggleGroup()
+				return m, nil
+			case "g":
+				m.list.Select(0)
+				m.skipHeader(true)
+				return m, nil
+			case "G":
+				m.list.Select(len(m.list.Items()) - 1)
+				m.skipHeader(false)
+				return m, nil
+			case "f":
+				if i, ok := m.list.SelectedItem().(item); ok {
+					cmd := m.toggleFavorite(i)
+					return m, cmd
+				}
+				return m, nil
+			case "e":
+				// Edit the definition, the list reloads afterwards
+				if i, ok := m.list.SelectedItem().(item); ok {
+					cmd := m.editScript(i)
+					return m, cmd
+				}
+				return m, nil
+			case "?":
+				m.openHelp()
+				return m, nil
+			case "v":
+				// Read the whole definition without running it
+				if i, ok := m.list.SelectedItem().(item); ok {
+					m.openPager(i)
+				}
+				return m, nil
+			case "c":
+				// Copy the command instead of running it
+				if i, ok := m.list.SelectedItem().(item); ok {
+					return m, m.copyCommand(i)
+				}
+				return m, nil
+			case " ":
+				// Queue the script to run in order with the others
+				if i, ok := m.list.SelectedItem().(item); ok {
+					m.toggleQueued(i)
+				}
+				return m, nil
+			case "E":
+				// Prompt for environment variables before running
+				if items := m.runTargets(); len(items) > 0 {
+					// Pre-fill with the config defaults and earlier values
+					value := joinArgs(mergeEnv(defaultEnv(m.envDefaults, items), m.env))
+					m.envField = huh.NewInput().
+						Title("Environment for " + itemNames(items)).
+						Placeholder("e.g. NODE_ENV=production PORT=3000").
+						Value(&value).
+						Key("env")
+					m.envForm = huh.NewForm(huh.NewGroup(m.envField)).WithShowHelp(false).WithShowErrors(false)
+					m.envFocused = true
+					m.envError = ""
+					return m, m.envForm.Init()
+				}
+			case "a":
+				// Prompt for extra arguments before running
+				if items := m.runTargets(); len(items) > 0 {
+					// Pre-fill with any arguments passed after "--"
+					value := joinArgs(m.extraArgs)
+					m.argsField = huh.NewInput().
+						Title("Arguments for " + itemNames(items)).
+						Placeholder("e.g. --watch or VERBOSE=1").
+						Value(&value).
+						Key("args")
+					m.argsForm = huh.NewForm(huh.NewGroup(m.argsField)).WithShowHelp(false).WithShowErrors(false)
+					m.argsFocused = true
+					m.argsError = ""
+					return m, m.argsForm.Init()
 				}
 			default:
-				// Pass key to list
+				// Pass key to list, stepping over source headers
+				before := m.list.Index()
 				m.list, cmd = m.list.Update(msg)
+				m.skipHeader(m.list.Index() >= before)
 				cmds = append(cmds, cmd)
 			}
-		}
+		}
+
+	case spinner.TickMsg:
+		if m.loading {
+			m.spinner, cmd = m.spinner.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+
+	case scriptsLoadedMsg:
+		if m.allItems == nil {
+			m.profile.track("picker ready", m.started)
+		}
+		m.loading = false
+		m.warnings = msg.warnings
+		announce := m.announceReload
+		m.announceReload = false
+		if msg.err != nil && m.allItems != nil {
+			// A reload failed, keep the scripts we had
+			m.error = "Reload failed: " + msg.err.Error()
+			return m, nil
+		}
+		if errors.Is(msg.err, errNoSource) {
+			// Offer to create some scripts instead of quitting
+			return m, m.openNoSource(msg.err)
+		}
+		if msg.err != nil {
+			m.loadErr = msg.err
+			return m, tea.Quit
+		}
+		m.sources = msg.sources
+		m.allItems = msg.items
+		m.locationKey = ""
+		m.favorites = msg.favorites
+		markQueued(m.allItems, m.queue)
+		m.list.Title = fmt.Sprintf("Available scripts from %s", strings.Join(sourceNames(m.sources, m.allItems), ", "))
+		if m.root != "" {
+			m.list.Title += " in " + m.root
+		}
+		// Keep anything typed into the filter while loading
+		m.applyFilter()
+		m.selectItem(m.reselect.source, m.reselect.name)
+		if m.restored {
+			m.restored = false
+			clearKey := "esc"
+			if m.filterFocused {
+				clearKey = "ctrl+u"
+			}
+			cmd := m.setStatus(fmt.Sprintf("Restored the filter %q, %s clears it", m.filterInput, clearKey), false)
+			return m, cmd
+		}
+		if announce {
+			cmd := m.setStatus(fmt.Sprintf("Reloaded %d scripts", countItems(m.allItems)), false)
+			return m, cmd
+		}
+		return m, nil
+
+	case editorFinishedMsg:
+		// Reload so changes to the script show up
+		m.reselect, _ = m.list.SelectedItem().(item)
+		return m.reload()
+
+	case signalMsg:
+		m.signal = msg.signal
+		m.quitting = true
+		return m, tea.Quit
+
+	case initFinishedMsg:
+		if msg.err != nil {
+			return m, m.setStatus("npm init failed: "+msg.err.Error(), true)
+		}
+		return m.reload()
+
+	case errMsg:
+		m.error = msg.err.Error()
+		return m, nil
+
+	case copiedMsg:
+		if msg.err != nil {
+			return m, m.setStatus("Copy failed: "+msg.err.Error(), true)
+		}
+		return m, m.setStatus("Copied! "+msg.command, false)
+
+	case clearStatusMsg:
+		// A newer status replaces this one and clears itself
+		if m.status == msg.status {
+			m.status = ""
+		}
+		return m, nil
+
+	case tea.MouseMsg:
+		return m.handleMouse(msg)
+
+	case tea.WindowSizeMsg:
+		h, v := docStyle.GetFrameSize()
+		m.width = msg.Width - h
+		m.height = msg.Height - v
+		if m.viewing {
+			m.resizePager()
+		}
+		listWidth, paneWidth := columnWidths(m.width)
+		m.paneWidth = paneWidth
+		m.list.SetSize(listWidth, msg.Height-v-5) // Reserve space for filter input, status and preview
+		
+		if m.form != nil {
+			var formCmd tea.Cmd
+			formModel, formCmd := m.form.Update(msg)
+			m.form = formModel.(*huh.Form)
+			cmds = append(cmds, formCmd)
+		}
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+// runItems selects items to run in order and quits, asking first for the
+// flags the config offers for the script
+func (m model) runItems(items []item, args []string) (model, tea.Cmd) {
+	// Stay in the list when a command can't be run, like when its tool
+	// isn't installed
+	for _, i := range items {
+		if _, _, err := m.sources[i.source].ResolveCommand(i.name, args); err != nil {
+			m.error = fmt.Sprintf("Can't run %s: %v", i.name, err)
+			return m, nil
+		}
+	}
+
+	if flags := scriptFlags(m.flagDefaults, items); len(flags) > 0 {
+		return m.openFlags(items, args, flags)
+	}
+	return m.confirmItems(items, args)
+}
+
+// confirmItems selects items to run with args and quits, asking for
+// confirmation first when a name matches one of the destructive script
+// patterns, or with a summary of every run for --confirm-all. A dry run
+// only prints the commands, so it never asks.
+func (m model) confirmItems(items []item, args []string) (model, tea.Cmd) {
+	destructive := []item{}
+	for _, i := range items {
+		if isDestructive(i.name, m.confirmPatterns) {
+			destructive = append(destructive, i)
+		}
+	}
+
+	if !m.dryRun && !m.printResult && (len(destructive) > 0 || m.confirmAll) {
+		title := fmt.Sprintf("Run %s?", itemNames(items))
+		if len(destructive) > 0 {
+			title = fmt.Sprintf("Really run %s?", itemNames(destructive))
+		}
+		m.pending = items
+		m.pendingArgs = args
+		m.confirmField = huh.NewConfirm().
+			Title(title).
+			Affirmative("Yes").
+			Negative("No").
+			Key("confirm")
+		m.confirmForm = huh.NewForm(huh.NewGroup(m.confirmField)).WithShowHelp(false).WithShowErrors(false)
+		m.confirming = true
+		return m, m.confirmForm.Init()
+	}
+
+	m.extraArgs = args
+	m.selected = items
+	return m, tea.Quit
+}
+
+// runTargets returns the items enter runs: the queue, or the highlighted
+// item when nothing is queued
+func (m model) runTargets() []item {
+	if len(m.queue) > 0 {
+		return m.queue
+	}
+	if i, ok := m.list.SelectedItem().(item); ok {
+		return []item{i}
+	}
+	return nil
+}
+
+// toggleQueued adds i to the end of the run queue or takes it out
+func (m *model) toggleQueued(i item) {
+	queue := []item{}
+	found := false
+	for _, queued := range m.queue {
+		if queued.source == i.source && queued.name == i.name {
+			found = true
+			continue
+		}
+		queue = append(queue, queued)
+	}
+	if !found {
+		queue = append(queue, i)
+	}
+	m.queue = queue
+	markQueued(m.allItems, m.queue)
+	m.applyFilter()
+	m.selectItem(i.source, i.name)
+}
+
+// markQueued numbers the items in queue by their position in it
+func markQueued(items []list.Item, queue []item) {
+	positions := make(map[string]int, len(queue))
+	for n, queued := range queue {
+		positions[favoriteKey(queued.source, queued.name)] = n + 1
+	}
+	for n, listItem := range items {
+		if i, ok := listItem.(item); ok {
+			i.queued = positions[favoriteKey(i.source, i.name)]
+			items[n] = i
+		}
+	}
+}
+
+// confirmRun finishes the confirmation, running the pending item or
+// returning to the list
+func (m model) confirmRun(confirmed bool) (model, tea.Cmd) {
+	m.confirming = false
+	if !confirmed {
+		return m, nil
+	}
+
+	m.extraArgs = m.pendingArgs
+	m.selected = m.pending
+	return m, tea.Quit
+}
+
+func (m model) View() string {
+	if m.loadErr != nil {
+		return ""
+	}
+
+	if m.quitting {
+		return successStyle.Render("Bye!\n")
+	}
+	
+	if m.noSource {
+		return m.noSourceView()
+	}
+
+	if m.viewing {
+		return m.pagerView()
+	}
+
+	if len(m.selected) > 0 && (m.dryRun || m.printResult) {
+		// main prints the commands itself
+		return ""
+	}
+
+	if len(m.selected) > 1 {
+		return successStyle.Render(fmt.Sprintf("Running: %s\n", itemNames(m.selected)))
+	}
+
+	if len(m.selected) == 1 {
+		// Show the command line the source will run
+		selected := m.selected[0]
+		command := selected.name
+		if _, args, err := m.sources[selected.source].ResolveCommand(selected.name, m.extraArgs); err == nil {
+			command = joinArgs(append(append([]string{}, m.env...), args...))
+		}
+		return successStyle.Render(fmt.Sprintf("Running: %s\n", command))
+	}
+	
+	// Combine filter input and list view
+	filterView := m.filterView()
+	listView := m.list.View()
+	if m.filterInput != "" && countItems(m.list.Items()) == 0 {
+		// Say why the list is empty, keeping its height so nothing jumps
+		noMatches := titleStyle.Render(m.list.Title) + "\n\n  " + previewStyle.Render(fmt.Sprintf("No matches for %q", m.filterInput))
+		listView = lipgloss.NewStyle().Height(m.list.Height()).Render(noMatches)
+	}
+	if m.error != "" {
+		// Make room for the banner by showing fewer items
+		banner := m.errorBanner()
+		l := m.list
+		l.SetHeight(max(l.Height()-lipgloss.Height(banner), 0))
+		listView = banner + "\n" + l.View()
+	}
+	if m.loading {
+		listView = "\n" + m.spinner.View() + " Looking for scripts…\n"
+	} else if m.flagsFocused {
+		// Pick the flags in place of the list, keeping its height
+		listView = lipgloss.NewStyle().Height(m.list.Height()).Render(m.flagsView())
+	} else if m.confirming && m.confirmAll {
+		// Show what runs in place of the list while asking
+		listView = lipgloss.NewStyle().Height(m.list.Height()).Render("\n" + m.runSummary(m.pending, m.pendingArgs))
+	} else if m.paneWidth > 0 {
+		listView = lipgloss.JoinHorizontal(lipgloss.Top,
+			lipgloss.NewStyle().Width(m.list.Width()+previewGap).Render(listView),
+			m.previewPane(lipgloss.Height(listView)))
+	}
+	
+	statusView := ""
+	if !m.loading {
+		statusView = "\n" + helpStyle.Render(m.statusLine())
+	}
+
+	// Preview the command for the highlighted item, unless the pane shows
+	// it, or show a status
+	previewView := "\n" + m.previewLine()
+	if m.paneWidth > 0 {
+		previewView = "\n"
+	}
+	if m.status != "" {
+		style := successStyle
+		if m.statusErr {
+			style = errorStyle
+		}
+		status := m.status
+		if m.width > 0 {
+			status = truncate.StringWithTail(status, uint(m.width), ellipsis)
+		}
+		previewView = "\n" + style.Render(status)
+	}
+	
+	// Add keyboard help
+	descState := "off"
+	if m.matchDesc {
+		descState = "on"
+	}
+	// Rebound keys are shown as configured
+	run := m.keys.help("run")
+	runHelp := run + ": run script"
+	if len(m.queue) > 0 && m.parallel {
+		runHelp = fmt.Sprintf("%s: run %d queued in parallel • esc: clear queue", run, len(m.queue))
+	} else if len(m.queue) > 0 {
+		runHelp = fmt.Sprintf("%s: run %d queued • esc: clear queue", run, len(m.queue))
+	}
+	if m.dryRun {
+		runHelp = run + ": print command • p: dry run off"
+	}
+	navigate := "↑/↓ or " + m.keys.first("up") + "/" + m.keys.first("down")
+	help := []string{
+		m.keys.help("filter") + ": filter",
+		"ctrl+d: descriptions " + descState,
+		navigate + ": navigate",
+		"g/G: top/bottom",
+		runHelp,
+		"space: queue",
+		"a: run with args",
+		"E: run with env",
+		"c: copy",
+		"v: view",
+		"e: edit",
+		"f: favorite",
+	}
+	if m.hasGroups() {
+		help = append(help, "z: fold group")
+	}
+	help = append(help,
+		m.keys.help("reload")+": reload",
+		"?: help",
+		m.keys.help("quit")+": quit",
+	)
+	helpText := "\n" + helpStyle.Render(strings.Join(help, " • "))
+	
+	return docStyle.Render(filterView + "\n" + listView + statusView + previewView + helpText)
+}
+
+// filterView renders the filter, or the prompt shown in its place
+func (m model) filterView() string {
+	if m.confirming {
+		// Ask before running a destructive script
+		return m.confirmForm.View()
+	}
+	if m.argsFocused {
+		// Show the arguments prompt in place of the filter
+		view := m.argsForm.View()
+		if m.argsError != "" {
+			view += "\n" + errorStyle.Render(m.argsError)
+		}
+		return view
+	}
+	if m.envFocused {
+		// Show the environment prompt in place of the filter
+		view := m.envForm.View()
+		if m.envError != "" {
+			view += "\n" + errorStyle.Render(m.envError)
+		}
+		return view
+	}
+	if m.form != nil {
+		return m.withMatchCount(m.form.View())
+	}
+	// If form is nil, create a simple filter input display
+	return filterStyle.Render("Filter: " + m.filterInput)
+}
+
+// withMatchCount shows matched/total at the right edge of the filter's input
+// line, like fzf, updated as the filter is typed
+func (m model) withMatchCount(view string) string {
+	if m.loading {
+		return view
+	}
+	count := helpStyle.Render(fmt.Sprintf("%d/%d", m.matched, countItems(m.allItems)))
+	lines := strings.Split(view, "\n")
+	// The input is the line after the title
+	line := min(1, len(lines)-1)
+	gap := max(m.width-lipgloss.Width(lines[line])-lipgloss.Width(count), 1)
+	lines[line] += strings.Repeat(" ", gap) + count
+	return strings.Join(lines, "\n")
+}
+
+// statusLine counts the scripts shown by the filter, and the filter text
+func (m model) statusLine() string {
+	total := countItems(m.allItems)
+	noun := "scripts"
+	if total == 1 {
+		noun = "script"
+	}
+	if m.filterInput == "" {
+		return fmt.Sprintf("%d %s", total, noun)
+	}
+	return fmt.Sprintf("showing %d of %d %s • filter: %s", countItems(m.list.Items()), total, noun, m.filterInput)
+}
+
+// countItems counts the scripts in items, leaving out the source headers
+func countItems(items []list.Item) int {
+	count := 0
+	for _, listItem := range items {
+		if _, ok := listItem.(item); ok {
+			count++
+		}
+	}
+	return count
+}
+
+// errMsg reports an error to show in the banner above the list
+type errMsg struct {
+	err error
+}
+
+// errorBanner renders m.error in a box that fits the window
+func (m model) errorBanner() string {
+	style := errorBannerStyle
+	if m.width > 0 {
+		style = style.Copy().Width(m.width - style.GetHorizontalFrameSize())
+	}
+	hint := previewStyle.Render("press any key to dismiss")
+	return style.Render(errorStyle.Render(m.error) + "\n" + hint)
+}
+
+// previewLine renders the resolved command for the highlighted item
+func (m model) previewLine() string {
+	i, ok := m.list.SelectedItem().(item)
+	if !ok {
+		return ""
+	}
+
+	command := i.command
+	if command == "" {
+		command = "(no recipe)"
+	}
+	// Fold multi-line recipes onto a single line
+	command = strings.Join(strings.Split(command, "\n"), " ⏎ ")
+
+	preview := "$ " + command
+	if m.width > 0 {
+		preview = truncate.StringWithTail(preview, uint(m.width), ellipsis)
+	}
+	return previewStyle.Render(preview)
+}
+
+// getDefaultInstallPath returns the default installation path for rx
+func getDefaultInstallPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "/usr/local/bin"
+	}
+	return filepath.Join(homeDir, ".local", "bin")
+}
+
+// shellConfigMarker is the comment init writes above the PATH line it adds
+const shellConfigMarker = "# Added by rx init"
+
+// getShellConfigPaths returns the common shell config files, in the order
+// init prefers them when the active shell is unknown
+func getShellConfigPaths() []string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	return []string{
+		filepath.Join(homeDir, ".zshrc"),
+		filepath.Join(homeDir, ".bashrc"),
+		filepath.Join(homeDir, ".bash_profile"),
+		fishConfigPath(homeDir),
+	}
+}
+
+// fishConfigPath returns the path to fish's config file
+func fishConfigPath(homeDir string) string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "fish", "config.fish")
+	}
+	return filepath.Join(homeDir, ".config", "fish", "config.fish")
+}
+
+// getShellConfigPath returns the path to the shell config file
+func getShellConfigPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	// Prefer the config of the active shell
+	switch filepath.Base(os.Getenv("SHELL")) {
+	case "fish":
+		return fishConfigPath(homeDir)
+	case "zsh":
+		return filepath.Join(homeDir, ".zshrc")
+	case "bash":
+		bashProfile := filepath.Join(homeDir, ".bash_profile")
+		if _, err := os.Stat(filepath.Join(homeDir, ".bashrc")); os.IsNotExist(err) {
+			if _, err := os.Stat(bashProfile); err == nil {
+				return bashProfile
+			}
+		}
+		return filepath.Join(homeDir, ".bashrc")
+	}
+
+	// Check for common shell config files
+	shellConfigs := getShellConfigPaths()
+	for _, config := range shellConfigs {
+		if _, err := os.Stat(config); err == nil {
+			return config
+		}
+	}
+	
+	// Default to .zshrc if none found
+	return shellConfigs[0]
+}
+
+// installRx installs rx to the specified path
+func installRx(installPath string) error {
+	// Get the path to the current executable, the file itself when rx runs
+	// from a symlink
+	exePath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to get executable path: %w", err)
+	}
+	exePath, err = filepath.EvalSymlinks(exePath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve executable path: %w", err)
+	}
+	
+	// Create the install directory if it doesn't exist
+	if err := os.MkdirAll(installPath, 0755); err != nil {
+		return fmt.Errorf("failed to create install directory: %w", err)
+	}
+	if err := checkWritable(installPath); err != nil {
+		return err
+	}
+	
+	// Copying rx over itself would truncate it before it's read
+	destPath := filepath.Join(installPath, "rx")
+	if sameFile(exePath, destPath) {
+		return errAlreadyInstalled
+	}
+
+	// Copy the executable next to the install path and rename it into
+	// place once it checks out, so an rx already installed keeps working
+	// until then, even while it's running. The rename replaces a symlink
+	// rather than writing through it to where it points.
+	src, err := os.Open(exePath)
+	if err != nil {
+		return fmt.Errorf("failed to open source file: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp(installPath, ".rx-install-*")
+	if err != nil {
+		return fmt.Errorf("failed to create destination file: %w", err)
+	}
+	tmpPath := dst.Name()
+
+	_, err = io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		// CreateTemp makes the file 0600
+		err = os.Chmod(tmpPath, 0755)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+
+	// Don't put a half-copied or unrunnable rx on the PATH
+	if err := verifyInstall(exePath, tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("copied rx is broken and wasn't installed: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to install %s: %w", destPath, err)
+	}
+	return nil
+}
+
+// errAlreadyInstalled is returned by installRx when the rx running is the
+// one in the install directory
+var errAlreadyInstalled = errors.New("rx is already installed there")
+
+// sameFile reports whether a and b are the same file, following symlinks.
+// A missing file is never the same.
+func sameFile(a, b string) bool {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(infoA, infoB)
+}
+
+// verifyInstall checks that the rx copied to destPath is identical to
+// srcPath, is executable and runs
+func verifyInstall(srcPath, destPath string) error {
+	info, err := os.Stat(destPath)
+	if err != nil {
+		return err
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0111 == 0 {
+		return fmt.Errorf("%s isn't executable (mode %v)", destPath, info.Mode().Perm())
+	}
+
+	srcSum, err := fileChecksum(srcPath)
+	if err != nil {
+		return err
+	}
+	destSum, err := fileChecksum(destPath)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(srcSum, destSum) {
+		return fmt.Errorf("%s differs from %s after copying", destPath, srcPath)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, destPath, "--version").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s --version failed: %w", destPath, err)
+	}
+	if !strings.HasPrefix(string(output), "rx ") {
+		return fmt.Errorf("%s --version printed %q", destPath, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// fileChecksum returns the SHA-256 of the file at path
+func fileChecksum(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return hash.Sum(nil), nil
+}
+
+// updateShellConfig adds the install path to PATH unless an earlier init
+// already added it
+func updateShellConfig(shellConfigPath, installPath string) error {
+	// Read the current shell config, which fish users may not have yet
+	content, err := os.ReadFile(shellConfigPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read shell config: %w", err)
+	}
+	
+	// Check for the block written by a previous init
+	pathLine := shellPathLine(shellConfigPath, installPath)
+	if hasShellConfigBlock(string(content), pathLine) {
+		return nil // Path already in config
+	}
+	
+	// Append the path to the config
+	if err := os.MkdirAll(filepath.Dir(shellConfigPath), 0755); err != nil {
+		return fmt.Errorf("failed to create shell config directory: %w", err)
+	}
+	f, err := os.OpenFile(shellConfigPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open shell config: %w", err)
+	}
+	defer f.Close()
+	
+	if _, err := f.WriteString("\n" + shellConfigMarker + "\n" + pathLine + "\n"); err != nil {
+		return fmt.Errorf("failed to update shell config: %w", err)
+	}
+	
+	return nil
+}
+
+// shellPathLine returns the line init adds to a shell config to put
+// installPath on PATH, in fish syntax for fish configs
+func shellPathLine(shellConfigPath, installPath string) string {
+	if filepath.Ext(shellConfigPath) == ".fish" {
+		return fmt.Sprintf("fish_add_path %q", installPath)
+	}
+	return fmt.Sprintf("export PATH=\"$PATH:%s\"", installPath)
+}
+
+// hasShellConfigBlock reports whether content contains the marker
+// directly followed by pathLine
+func hasShellConfigBlock(content, pathLine string) bool {
+	lines := strings.Split(content, "\n")
+	for i := 0; i+1 < len(lines); i++ {
+		if lines[i] == shellConfigMarker && lines[i+1] == pathLine {
+			return true
+		}
+	}
+	return false
+}
+
+// removeShellConfigBlock strips the marker and PATH line that init added
+// from a shell config. Only the exact two lines are removed, with the blank
+// line init wrote before them. It reports whether anything was removed.
+func removeShellConfigBlock(content, pathLine string) (string, bool) {
+	lines := strings.Split(content, "\n")
+
+	kept := make([]string, 0, len(lines))
+	removed := false
+	for i := 0; i < len(lines); i++ {
+		if lines[i] == shellConfigMarker && i+1 < len(lines) && lines[i+1] == pathLine {
+			if n := len(kept); n > 0 && kept[n-1] == "" {
+				kept = kept[:n-1]
+			}
+			i++
+			removed = true
+			continue
+		}
+		kept = append(kept, lines[i])
+	}
+
+	if !removed {
+		return content, false
+	}
+	return strings.Join(kept, "\n"), true
+}
+
+// handleUninstall handles the uninstall command, reversing what init did,
+// from --path when rx was installed there
+func handleUninstall(opts options) {
+	installPath := getDefaultInstallPath()
+	if opts.installPath != "" {
+		path, err := expandInstallPath(opts.installPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Error with --path: %v", err)))
+			os.Exit(1)
+		}
+		installPath = path
+	}
+	removedAny := false
+
+	binPath := filepath.Join(installPath, "rx")
+	if err := os.Remove(binPath); err == nil {
+		fmt.Println(successStyle.Render("Removed " + binPath))
+		removedAny = true
+	} else if !os.IsNotExist(err) {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error removing %s: %v", binPath, err)))
+		os.Exit(1)
+	}
+
+	// init only writes to one config, but it may have been run from a
+	// different shell, so check all of them
+	for _, shellConfigPath := range getShellConfigPaths() {
+		content, err := os.ReadFile(shellConfigPath)
+		if err != nil {
+			continue
+		}
+		updated, ok := removeShellConfigBlock(string(content), shellPathLine(shellConfigPath, installPath))
+		if !ok {
+			continue
+		}
+		if err := os.WriteFile(shellConfigPath, []byte(updated), 0644); err != nil {
+			fmt.Println(errorStyle.Render(fmt.Sprintf("Error updating shell config: %v", err)))
+			os.Exit(1)
+		}
+		fmt.Println(successStyle.Render("Removed the PATH entry for " + installPath + " from " + shellConfigPath))
+		removedAny = true
+	}
+
+	if !removedAny {
+		fmt.Println("rx is not installed in " + installPath + ", nothing to remove")
+		return
+	}
+	fmt.Println(successStyle.Render("\nrx has been uninstalled"))
+}
+
+// handleInit handles the init command. --path installs somewhere other
+// than ~/.local/bin, --no-shell-config leaves PATH to the user and --quiet
+// only prints errors.
+func handleInit(opts options) {
+	say := func(message string) {
+		if !opts.quiet {
+			fmt.Println(message)
+		}
+	}
+	fail := func(message string, err error) {
+		fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("%s: %v", message, err)))
+		os.Exit(1)
+	}
+
+	installPath := getDefaultInstallPath()
+	if opts.installPath != "" {
+		path, err := expandInstallPath(opts.installPath)
+		if err != nil {
+			fail("Error with --path", err)
+		}
+		installPath = path
+	}
+	shellConfigPath := ""
+	if !opts.noShellConfig {
+		shellConfigPath = getShellConfigPath()
+	}
+
+	say(successStyle.Render(fmt.Sprintf("Installing rx %s to %s", version, installPath)))
+
+	if err := installRx(installPath); errors.Is(err, errAlreadyInstalled) {
+		say("rx in " + installPath + " is the one running, leaving it in place")
+	} else if err != nil {
+		fail("Error installing rx", err)
+	}
+
+	if shellConfigPath != "" {
+		say(successStyle.Render("Updating shell config at " + shellConfigPath))
+		if err := updateShellConfig(shellConfigPath, installPath); err != nil {
+			fail("Error updating shell config", err)
+		}
+	}
+
+	say(successStyle.Render("\nrx has been installed successfully!"))
+	say("Installed " + versionString())
+	if shellConfigPath != "" {
+		say("To use rx from any directory, restart your terminal or run:")
+		say("  source " + shellConfigPath)
+	} else {
+		say("Make sure " + installPath + " is on your PATH")
+	}
+}
+
+// expandInstallPath makes the --path of init absolute, expanding a leading
+// ~ that the shell left alone, as in --path=~/bin
+func expandInstallPath(path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(homeDir, path[1:])
+	}
+	return filepath.Abs(path)
+}
+
+// checkWritable reports an error when files can't be created in dir
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".rx-install-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", dir, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// printCommand prints the command line that would run name, quoted so it
+// can be pasted into a shell
+func printCommand(w io.Writer, source ScriptSource, name string, extraArgs, env []string) error {
+	_, args, err := source.ResolveCommand(name, extraArgs)
+	if err != nil {
+		return err
+	}
+	// Variables go first, as a shell takes them
+	fmt.Fprintln(w, joinArgs(append(append([]string{}, env...), args...)))
+	return nil
+}
+
+// handleRun handles the run command, running a script by name without the
+// interactive picker
+func handleRun(opts options, cfg Config, args []string) {
+	if opts.watch && len(args) > 1 {
+		fmt.Fprintln(os.Stderr, "rx: --watch runs a single script")
+		os.Exit(2)
+	}
+
+	sources, items, err := findScriptSources(opts.sourceOptions(cfg))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+		printNoSourceHint(err)
+		os.Exit(1)
+	}
+
+	// Without a name, run the default task of the rx file
+	if len(args) == 0 {
+		name := defaultScript(sources)
+		if name == "" {
+			fmt.Fprintln(os.Stderr, "rx: run requires a script name, or a default task in .rx.yaml")
+			fmt.Fprintln(os.Stderr, "usage: rx run <name>... [-- args...]")
+			os.Exit(2)
+		}
+		args = []string{name}
+	}
+
+	selected := []item{}
+	for _, name := range args {
+		var i item
+		var ok bool
+		var candidates []string
+		if opts.firstMatch {
+			i, candidates, ok = firstMatch(name, items)
+		} else {
+			i, ok = findItem(items, name)
+		}
+		if len(candidates) > 1 {
+			fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Error: %q matches %d scripts, name one of them:", name, len(candidates))))
+			for _, candidate := range candidates {
+				fmt.Fprintln(os.Stderr, "  "+candidate)
+			}
+			os.Exit(1)
+		}
+		if !ok {
+			fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Error: no script named %q", name)))
+			if suggestions := closeMatches(name, items, 5); len(suggestions) > 0 {
+				fmt.Fprintln(os.Stderr, "Did you mean:")
+				for _, suggestion := range suggestions {
+					fmt.Fprintln(os.Stderr, "  "+suggestion)
+				}
+			}
+			os.Exit(1)
+		}
+		if i.name != name {
+			fmt.Fprintf(os.Stderr, "rx: running %s\n", i.name)
+		}
+		if others := sourcesOf(items, i.name); len(others) > 1 {
+			fmt.Fprintf(os.Stderr, "rx: warning: %q is a script of %s, running the %s one; pick another with --source\n", i.name, strings.Join(others, " and "), i.source)
+		}
+		selected = append(selected, i)
+	}
+
+	// Before the scripts run, which may replace rx
+	opts.profile.print(os.Stderr)
+
+	if opts.dryRun {
+		for _, i := range selected {
+			if err := printCommand(os.Stdout, sources[i.source], i.name, opts.extraArgs, nil); err != nil {
+				fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+				os.Exit(1)
+			}
+		}
+		return
+	}
+
+	cwd, _ := os.Getwd()
+	for _, i := range selected {
+		if err := recordHistory(cwd, i.name, i.source); err != nil {
+			fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Warning: could not save history: %v", err)))
+		}
+		if err := recordStats(cwd, i.name, i.source); err != nil {
+			fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Warning: could not save stats: %v", err)))
+		}
+	}
+	if cfg.Log {
+		if err := logRuns(cwd, sources, selected, opts.extraArgs); err != nil {
+			fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Warning: could not write the run log: %v", err)))
+		}
+	}
+
+	env := opts.dotenv()
+	if len(selected) > 1 {
+		if opts.parallel {
+			os.Exit(runParallel(sources, selected, opts.extraArgs, env))
+		}
+		os.Exit(runSequence(sources, selected, opts.extraArgs, env))
+	}
+
+	i := selected[0]
+	if opts.watch {
+		if err := watchScript(sources[i.source], i.name, opts.extraArgs, env, opts.watchGlobs); err != nil {
+			fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+			os.Exit(1)
+		}
+		return
+	}
+	if mode := opts.tmuxMode(cfg); mode != "" {
+		if err := runInTmux(mode, sources[i.source], i.name, opts.extraArgs, env); err != nil {
+			fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+			os.Exit(1)
+		}
+		return
+	}
+
+	err = sources[i.source].RunScript(i.name, opts.extraArgs, env)
+	fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Error executing script: %v", err)))
+	os.Exit(1)
+}
+
+// findItem returns the item called name. Sources are in priority order, so
+// the first exact match wins.
+func findItem(items []list.Item, name string) (item, bool) {
+	for _, listItem := range items {
+		if i, ok := listItem.(item); ok && i.name == name {
+			return i, true
+		}
+	}
+	return item{}, false
+}
+
+// sourcesOf lists the sources that have a script named name
+func sourcesOf(items []list.Item, name string) []string {
+	sources := []string{}
+	for _, listItem := range items {
+		if i, ok := listItem.(item); ok && i.name == name && !contains(sources, i.source) {
+			sources = append(sources, i.source)
+		}
+	}
+	return sources
+}
+
+// listedScript is the JSON shape of a script printed by --list --json
+type listedScript struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Command     string `json:"command"`
+	Source      string `json:"source"`
+}
+
+// handleList prints the scripts without starting the TUI, one per line as
+// name, description and source separated by tabs, or as a JSON array
+func handleList(w io.Writer, items []list.Item, asJSON bool) error {
+	scripts := []listedScript{}
+	for _, listItem := range items {
+		if i, ok := listItem.(item); ok {
+			scripts = append(scripts, listedScript{Name: i.name, Description: i.description, Command: i.command, Source: i.source})
+		}
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(scripts)
+	}
+
+	// Keep each script on one line so the output can be piped
+	oneLine := strings.NewReplacer("\t", " ", "\n", " ")
+	for _, script := range scripts {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", script.Name, oneLine.Replace(script.Description), script.Source)
+	}
+	return nil
+}
+
+// sortItemsByName sorts items alphabetically in place
+func sortItemsByName(items []list.Item) {
+	sort.SliceStable(items, func(a, b int) bool {
+		return items[a].FilterValue() < items[b].FilterValue()
+	})
+}
+
+// sortItems orders items by the sort order. By default scripts recently run
+// in dir come first, followed by each source's scripts sorted by name, and
+// without any history for dir everything is sorted by name.
+// Filtering keeps this order among matches that score the same.
+func sortItems(items []list.Item, order, dir string) []list.Item {
+	switch order {
+	case "name":
+		sortItemsByName(items)
+	case "source":
+		// Keep the order the sources returned
+	case "frequency":
+		if stats, err := loadStats(dir); err == nil {
+			items = sortByFrequency(items, stats)
+		}
+	default:
+		history, err := loadHistory()
+		if err != nil || !ranIn(history, dir) {
+			sortItemsByName(items)
+			break
+		}
+		items = sortByHistory(items, history, dir)
+	}
+	return items
+}
+
+// firstMatch returns the script named query, or else the only script it
+// fuzzy matches, for rx -1. When it matches several, they are returned
+// best match first instead. A name shared by several sources counts once.
+func firstMatch(query string, items []list.Item) (item, []string, bool) {
+	if i, ok := findItem(items, query); ok {
+		return i, nil, true
+	}
+
+	candidates := []string{}
+	for _, name := range closeMatches(query, items, len(items)) {
+		if !contains(candidates, name) {
+			candidates = append(candidates, name)
+		}
+	}
+	if len(candidates) != 1 {
+		return item{}, candidates, false
+	}
+	i, ok := findItem(items, candidates[0])
+	return i, nil, ok
+}
+
+// closeMatches returns up to limit script names that fuzzy match name,
+// best match first
+func closeMatches(name string, items []list.Item, limit int) []string {
+	type scored struct {
+		name  string
+		score int
+	}
+	matches := []scored{}
+	for _, listItem := range items {
+		if score, _, ok := fuzzyMatch(name, listItem.FilterValue()); ok {
+			matches = append(matches, scored{name: listItem.FilterValue(), score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	names := []string{}
+	for _, match := range matches {
+		if len(names) == limit {
+			break
+		}
+		names = append(names, match.name)
+	}
+	return names
+}
+
+// usage is printed by --help and when an unknown command is given
+const usage = `rx - find and run scripts from package.json or a Makefile
+
+Usage:
+  rx [-- args...]     Pick a script interactively and run it
+  rx run <name>... [-- args...]
+                      Run scripts by name without the picker, one after
+                      another or with --parallel at the same time. Without
+                      a name, runs the default task of .rx.yaml
+  rx -1 <query>       Run the script whose name query fuzzy matches, like
+                      bld for build, refusing when it matches several
+  rx stats            Show how often each script ran here, with --json as
+                      JSON
+  rx init             Install rx to ~/.local/bin and add it to your PATH
+                      --path <dir>       install to dir instead
+                      --no-shell-config  don't edit your shell config
+                      --quiet            only print errors
+  rx uninstall        Remove rx from ~/.local/bin and your PATH, or from
+                      --path <dir>
+  rx completion <shell>
+                      Print a completion script for bash, zsh or fish
+
+Flags:
+  -h, --help          Show this help
+  -v, --version       Show the rx version
+  -C, --cwd <dir>     Find and run scripts in dir instead of the current
+                      directory
+  --recent-dirs       Pick one of the directories rx recently ran scripts
+                      in, then pick a script there
+  -l, --list          Print scripts as name, description and source
+  --json              Print scripts as a JSON array
+  --clear-history     Forget recently run scripts
+  --dry-run           Print the command for the selected script instead
+                      of running it
+  --confirm-all       Show the command, directory, source and environment
+                      of the selected script and ask before running it
+  --print-result      Print the chosen script as JSON instead of running
+                      it, drawing the picker on stderr
+  --parallel          Run queued scripts at the same time, prefixing
+                      their output with the script name
+  --watch             Run the selected script and run it again whenever a
+                      file in the project changes
+  --watch-glob <glob> Only restart for files matching glob, like *.go or
+                      src/*.ts. Can be repeated, implies --watch
+  --sort <order>      Order scripts by recent (default), frequency, the
+                      scripts run most often here first, name or source,
+                      the order they appear in their file
+  --theme <name>      Colors: dark, light, high-contrast or monochrome.
+                      Picked from the terminal's background by default
+  --no-color          Print plain text, also when NO_COLOR is set or
+                      output isn't a terminal
+  --source <tag>      Use only this source instead of detecting them: rx,
+                      npm, make, just, task, composer, cargo, gradle, maven,
+                      poetry, tox, rake, compose or scripts
+  --no-env            Don't load .env and .env.local into the environment
+                      of scripts
+  --env-override      Let .env files override variables that are already
+                      set
+  --no-search-up      Only look for scripts in the current directory, not
+                      in the nearest parent directory that has some
+  --select-first      Start in the list with the first script highlighted
+                      instead of in the filter
+  --auto-run          Run a script as soon as the filter matches only it
+  --tmux <mode>       Inside tmux, run the script in a new pane (split) or
+                      window instead of in place
+  --shell             Run scripts through your login shell, $SHELL -lc, so
+                      they get its PATH. The default for npm scripts when
+                      there is an .nvmrc or .node-version
+  --use-nvm           Switch to the node version of .nvmrc or .node-version
+                      with nvm use before running npm scripts
+  --pm-fallback       Run npm scripts with npm when the yarn or pnpm of the
+                      lockfile isn't installed, instead of failing
+  --no-mouse          Don't select and scroll scripts with the mouse, so
+                      the terminal can select text
+  --timeout <d>       Give up on commands listing scripts, like make -pn,
+                      after d (default 10s, 0 waits forever)
+  --no-cache          Run make -pn instead of using cached make targets
+  --profile           Print how long detecting and listing each source and
+                      starting the picker took, to stderr
+  --include-internal  List internal make targets too, like .init, .PHONY
+                      and file targets left out by .PHONY
+  --show-hooks        List npm pre/post hooks and lifecycle scripts, which
+                      are hidden by default
+  --npm-defaults      Offer install, test and start when package.json
+                      has no scripts
+
+Script sources:
+  .rx.yaml            rx's own tasks, shell commands run with $SHELL -c.
+                      Also .rx.toml
+  package.json        npm scripts, run with npm, yarn or pnpm
+  Makefile            make targets
+  justfile            just recipes
+  Taskfile.yml        go-task tasks
+  composer.json       composer scripts
+  Cargo.toml          cargo subcommands and aliases
+  build.gradle        Gradle tasks, run with ./gradlew when present
+  pom.xml             Maven lifecycle phases and profiles
+  pyproject.toml      poetry and project scripts, run with poetry run
+  tox.ini             tox environments, run with tox -e
+  Rakefile            rake tasks with a description
+  compose.yaml        up, run, logs and restart for Docker Compose services
+  scripts/            executable files in scripts/ or .rx/, described by a
+                      "# description:" comment
+
+Key bindings:
+  / or ctrl+f         Focus the filter
+  enter, tab, down    Move from the filter to the list
+  ctrl+d              Also match descriptions when filtering
+  ctrl+u              Clear the filter while typing in it
+  ↑/↓ or j/k          Navigate through scripts
+  g/G                 Jump to the first or last script
+  enter               Run the selected script, or the queued scripts
+  space               Queue the selected script. Queued scripts run in
+                      order, stopping at the first that fails
+  a                   Run the selected script with extra arguments
+  E                   Run the selected script with environment variables,
+                      entered as KEY=VALUE pairs
+  p                   Toggle dry run, printing the command instead
+  c                   Copy the command of the selected script
+  e                   Edit the selected script in $EDITOR, for npm scripts
+                      and make targets
+  f                   Star the selected script, listing it first
+  r                   Read the scripts again after editing their files
+  esc                 From the filter, go back to the list. From the list,
+                      clear the filter, then the queue, then quit
+  q                   Quit from the list, press twice while filtering
+  ?                   Show every key, as bound in the config
+  ctrl+c              Quit from anywhere
+`
+
+// printUsage writes the usage summary to w
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, usage)
+}
+
+// versionString describes the build for --version and init
+func versionString() string {
+	return fmt.Sprintf("rx %s (%s, %s/%s)", version, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
+
+// sourceOptions configures how script sources are detected and enumerated
+type sourceOptions struct {
+	PackageManager  string        // overrides lockfile detection when set
+	NPMDefaults     bool          // offer install/test/start when package.json has no scripts
+	SourceOrder     bool          // keep each source's file order instead of sorting by name
+	NoCache         bool          // don't use cached make targets
+	Timeout         time.Duration // limit for commands listing scripts, 0 for none
+	ShowHooks       bool          // list npm pre/post and lifecycle scripts too
+	IncludeInternal bool          // list internal make targets like .init too
+	Shell           bool          // run scripts through the login shell
+	UseNVM          bool          // switch to the pinned node version with nvm use
+	PMFallback      bool          // run npm scripts with npm when the package manager is missing
+	Source          string        // use only the source with this tag, skipping detection
+	ScriptsDir      string        // scripts_dir, listed alongside other sources when set
+	Profile         *profiler     // times detection and each source for --profile
+
+	// Warn is called with the error of each detected source that failed
+	// to list scripts, since the other sources are still used
+	Warn func(error)
+}
+
+// detectedSource is a source found by its detector, to list scripts from
+type detectedSource struct {
+	detector sourceDetector
+	source   ScriptSource
+}
+
+// sourceResult is what a source listed, or why it couldn't
+type sourceResult struct {
+	items []list.Item
+	err   error
+}
+
+// sourceWaitGrace is how much longer than the timeout listSources waits
+// for a source, as the command it ran may take a moment to be killed
+const sourceWaitGrace = 2 * time.Second
+
+// listSources lists the scripts of every source at the same time, so the
+// slow ones like make -pn and gradle tasks don't add up, and returns the
+// results in the order of detected. A source still listing after wait
+// fails with errListTimeout while the others are kept, 0 waits forever.
+func listSources(detected []detectedSource, wait time.Duration, profile *profiler) []sourceResult {
+	results := make([]sourceResult, len(detected))
+	done := make(chan int, len(detected))
+	for index, d := range detected {
+		go func(index int, d detectedSource) {
+			start := time.Now()
+			items, err := d.source.GetScripts()
+			phase := fmt.Sprintf("%s scripts from %s", d.detector.tag, d.detector.file)
+			if err != nil {
+				phase += " (failed)"
+			}
+			profile.track(phase, start)
 
-	case tea.WindowSizeMsg:
-		h, v := docStyle.GetFrameSize()
-		m.list.SetSize(msg.Width-h, msg.Height-v-3) // Reserve space for filter input
-		
-		if m.form != nil {
-			var formCmd tea.Cmd
-			formModel, formCmd := m.form.Update(msg)
-			m.form = formModel.(*huh.Form)
-			cmds = append(cmds, formCmd)
+			// Each goroutine writes only its own result, read once it's done
+			results[index] = sourceResult{items: items, err: err}
+			done <- index
+		}(index, d)
+	}
+
+	var timeout <-chan time.Time
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	finished := make([]bool, len(detected))
+	for remaining := len(detected); remaining > 0; remaining-- {
+		select {
+		case index := <-done:
+			finished[index] = true
+		case <-timeout:
+			// Give up on the stuck sources, their goroutines finish in the
+			// background and their results are never read
+			stuck := make([]sourceResult, len(detected))
+			for index, d := range detected {
+				if finished[index] {
+					stuck[index] = results[index]
+				} else {
+					stuck[index].err = fmt.Errorf("%s: listing scripts %w after %v", d.detector.file, errListTimeout, wait)
+				}
+			}
+			return stuck
 		}
 	}
+	return results
+}
 
-	return m, tea.Batch(cmds...)
+// sourceDetector describes how a script source is detected and created
+type sourceDetector struct {
+	tag     string                        // the item.source tag of its items, used by --source
+	file    string                        // the file that marks the source, for messages
+	find    func(opts sourceOptions) bool // reports whether the source's file exists
+	require func() error                  // checks the tool is installed, nil when RunScript does
+	create  func(opts sourceOptions) ScriptSource
+
+	// lastResort sources are only listed when no other source has any
+	// scripts, unless the config names their file, see
+	// sourceOptions.ScriptsDir
+	lastResort bool
 }
 
-func (m model) View() string {
-	if m.quitting {
-		return successStyle.Render("Bye!\n")
-	}
-	
-	if m.error != "" {
-		return errorStyle.Render(m.error + "\n")
+// fileExists returns a find func that reports whether name exists
+func fileExists(name string) func(sourceOptions) bool {
+	return func(sourceOptions) bool {
+		_, err := os.Stat(name)
+		return err == nil
 	}
-	
-	if m.selected != "" {
-		// Use the source name for the running message
-		var cmdName string
-		if _, ok := m.source.(*NPMScriptSource); ok {
-			cmdName = "npm run"
-		} else {
-			cmdName = "make"
+}
+
+// lookPath returns a require func that checks name is on PATH
+func lookPath(name string) func() error {
+	return func() error {
+		if _, err := exec.LookPath(name); err != nil {
+			return fmt.Errorf("%s not found: %w", name, err)
 		}
-		return successStyle.Render(fmt.Sprintf("Running: %s %s\n", cmdName, m.selected))
-	}
-	
-	// Combine filter input and list view
-	var filterView string
-	if m.form != nil {
-		filterView = m.form.View()
-	} else {
-		// If form is nil, create a simple filter input display
-		filterStyle := lipgloss.NewStyle().Padding(0, 1).Border(lipgloss.NormalBorder(), false, false, true, false).BorderForeground(lipgloss.Color("#61AFEF"))
-		filterView = filterStyle.Render("Filter: " + m.filterInput)
+		return nil
 	}
-	
-	listView := m.list.View()
-	
-	// Add keyboard help
-	helpText := "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("#5C6370")).Render(
-		"/ or ctrl+f: filter • ↑/↓: navigate • enter: run script • q: quit",
-	)
-	
-	return docStyle.Render(filterView + "\n" + listView + helpText)
 }
 
-// getDefaultInstallPath returns the default installation path for rx
-func getDefaultInstallPath() string {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "/usr/local/bin"
+// sourceDetectors lists the script sources in priority order
+var sourceDetectors = []sourceDetector{
+	{
+		// rx's own task file comes first, it's there to be used
+		tag:    "rx",
+		file:   ".rx.yaml",
+		find:   func(sourceOptions) bool { return findRxFile() != "" },
+		create: func(sourceOptions) ScriptSource { return &RxFileScriptSource{} },
+	},
+	{
+		// The package manager binary is checked in RunScript so a missing
+		// yarn or pnpm produces a clear error instead of skipping the source
+		tag:  "npm",
+		file: "package.json",
+		find: fileExists("package.json"),
+		create: func(opts sourceOptions) ScriptSource {
+			manager := opts.PackageManager
+			if manager == "" {
+				manager = detectPackageManager()
+			}
+			source := &NPMScriptSource{Manager: manager, SynthesizeCommands: opts.NPMDefaults, ShowHooks: opts.ShowHooks, Fallback: opts.PMFallback}
+			source.NodeVersion, source.NodeVersionFile = requiredNodeVersion()
+			return source
+		},
+	},
+	{
+		tag:     "make",
+		file:    "Makefile",
+		find:    fileExists("Makefile"),
+		require: lookPath("make"),
+		create: func(opts sourceOptions) ScriptSource {
+			return &MakefileScriptSource{NoCache: opts.NoCache, Timeout: opts.Timeout, IncludeInternal: opts.IncludeInternal}
+		},
+	},
+	{
+		tag:     "just",
+		file:    "justfile",
+		find:    func(sourceOptions) bool { return findJustfile() != "" },
+		require: lookPath("just"),
+		create:  func(opts sourceOptions) ScriptSource { return &JustScriptSource{Timeout: opts.Timeout} },
+	},
+	{
+		tag:     "task",
+		file:    "Taskfile.yml",
+		find:    func(sourceOptions) bool { return findTaskfile() != "" },
+		require: lookPath("task"),
+		create:  func(sourceOptions) ScriptSource { return &TaskfileScriptSource{} },
+	},
+	{
+		// PHP projects
+		tag:     "composer",
+		file:    "composer.json",
+		find:    fileExists("composer.json"),
+		require: lookPath("composer"),
+		create:  func(sourceOptions) ScriptSource { return &ComposerScriptSource{} },
+	},
+	{
+		// Rust projects
+		tag:     "cargo",
+		file:    "Cargo.toml",
+		find:    fileExists("Cargo.toml"),
+		require: lookPath("cargo"),
+		create:  func(sourceOptions) ScriptSource { return &CargoScriptSource{} },
+	},
+	{
+		// A Gradle build can run through its wrapper without a gradle
+		// install
+		tag:  "gradle",
+		file: "build.gradle",
+		find: func(sourceOptions) bool { return isGradleProject() },
+		require: func() error {
+			_, err := findGradle()
+			return err
+		},
+		create: func(opts sourceOptions) ScriptSource { return &GradleScriptSource{Timeout: opts.Timeout} },
+	},
+	{
+		tag:     "maven",
+		file:    "pom.xml",
+		find:    fileExists("pom.xml"),
+		require: lookPath("mvn"),
+		create:  func(sourceOptions) ScriptSource { return &MavenScriptSource{} },
+	},
+	{
+		// Python projects can have both pyproject.toml scripts and tox
+		// environments, which are listed under their own headers
+		tag:     "poetry",
+		file:    "pyproject.toml",
+		find:    fileExists("pyproject.toml"),
+		require: lookPath("poetry"),
+		create:  func(sourceOptions) ScriptSource { return &PyProjectScriptSource{} },
+	},
+	{
+		tag:     "tox",
+		file:    "tox.ini",
+		find:    fileExists("tox.ini"),
+		require: lookPath("tox"),
+		create:  func(sourceOptions) ScriptSource { return &ToxScriptSource{} },
+	},
+	{
+		// Ruby projects
+		tag:     "rake",
+		file:    "Rakefile",
+		find:    func(sourceOptions) bool { return findRakefile() != "" },
+		require: lookPath("rake"),
+		create:  func(opts sourceOptions) ScriptSource { return &RakeScriptSource{Timeout: opts.Timeout} },
+	},
+	{
+		tag:     "compose",
+		file:    "compose.yaml",
+		find:    func(sourceOptions) bool { return findComposeFile() != "" },
+		require: lookPath("docker"),
+		create:  func(sourceOptions) ScriptSource { return &ComposeScriptSource{} },
+	},
+	{
+		// A last resort for projects without a task runner, as plenty of
+		// npm and make projects keep helper scripts in scripts/
+		tag:        "scripts",
+		file:       "scripts/",
+		find:       func(opts sourceOptions) bool { return findScriptDir(opts.ScriptsDir) != "" },
+		create:     func(opts sourceOptions) ScriptSource { return &DirScriptSource{Dir: findScriptDir(opts.ScriptsDir)} },
+		lastResort: true,
+	},
+}
+
+// sourceTags lists the tags of all script sources, the values of --source
+func sourceTags() []string {
+	tags := make([]string, len(sourceDetectors))
+	for i, detector := range sourceDetectors {
+		tags[i] = detector.tag
 	}
-	return filepath.Join(homeDir, ".local", "bin")
+	return tags
 }
 
-// getShellConfigPath returns the path to the shell config file
-func getShellConfigPath() string {
-	homeDir, err := os.UserHomeDir()
+// findScriptSources detects every script source in the current directory.
+// It returns the sources keyed by the tag their items carry in item.source
+// ("npm", "make", ...) so runs dispatch to the right source, along with the
+// combined items in priority order. With opts.Source set, only that source
+// is used and any problem with it is an error.
+func findScriptSources(opts sourceOptions) (map[string]ScriptSource, []list.Item, error) {
+	sources := make(map[string]ScriptSource)
+	items := []list.Item{}
+
+	// Scripts hidden by .rxignore are dropped from every source
+	var failures []error
+	rules, err := loadIgnoreRules(!opts.ShowHooks)
 	if err != nil {
-		return ""
+		failures = append(failures, err)
 	}
-	
-	// Check for common shell config files
-	shellConfigs := []string{
-		filepath.Join(homeDir, ".zshrc"),
-		filepath.Join(homeDir, ".bashrc"),
-		filepath.Join(homeDir, ".bash_profile"),
+
+	addSource := func(source ScriptSource, result sourceResult) {
+		if result.err != nil {
+			failures = append(failures, result.err)
+			return
+		}
+		sourceItems := removeIgnored(result.items, rules)
+		if len(sourceItems) == 0 {
+			return
+		}
+		for _, listItem := range sourceItems {
+			if i, ok := listItem.(item); ok {
+				sources[i.source] = source
+			}
+		}
+		if !opts.SourceOrder {
+			sortItemsByName(sourceItems)
+		}
+		items = append(items, sourceItems...)
 	}
-	
-	for _, config := range shellConfigs {
-		if _, err := os.Stat(config); err == nil {
-			return config
+
+	var detected, lastResort []detectedSource
+	start := time.Now()
+	for _, detector := range sourceDetectors {
+		if opts.Source != "" && detector.tag != opts.Source {
+			continue
 		}
+
+		if !detector.find(opts) {
+			if opts.Source != "" {
+				return nil, nil, fmt.Errorf("--source %s: %s not found", opts.Source, detector.file)
+			}
+			continue
+		}
+		if detector.require != nil {
+			if err := detector.require(); err != nil {
+				if opts.Source != "" {
+					return nil, nil, fmt.Errorf("--source %s: %w", opts.Source, err)
+				}
+				continue
+			}
+		}
+		// nvm and the like only set up node in the login shell
+		source := detector.create(opts)
+		if npm, ok := source.(*NPMScriptSource); ok && npm.NodeVersion != "" {
+			shell := &shellSource{ScriptSource: source}
+			if opts.UseNVM {
+				shell.Setup = nvmUse(npm.NodeVersion)
+			}
+			source = shell
+		} else if opts.Shell {
+			source = &shellSource{ScriptSource: source}
+		}
+		if detector.lastResort && opts.Source == "" && opts.ScriptsDir == "" {
+			lastResort = append(lastResort, detectedSource{detector: detector, source: source})
+			continue
+		}
+		detected = append(detected, detectedSource{detector: detector, source: source})
 	}
-	
-	// Default to .zshrc if none found
-	return filepath.Join(homeDir, ".zshrc")
-}
+	opts.Profile.track("detect sources", start)
 
-// installRx installs rx to the specified path
-func installRx(installPath string) error {
-	// Get the path to the current executable
-	exePath, err := os.Executable()
-	if err != nil {
-		return fmt.Errorf("failed to get executable path: %w", err)
+	// Commands listing scripts are killed at the timeout, a source still
+	// running a moment after is stuck some other way
+	wait := time.Duration(0)
+	if opts.Timeout > 0 {
+		wait = opts.Timeout + sourceWaitGrace
 	}
-	
-	// Create the install directory if it doesn't exist
-	if err := os.MkdirAll(installPath, 0755); err != nil {
-		return fmt.Errorf("failed to create install directory: %w", err)
+	for index, result := range listSources(detected, wait, opts.Profile) {
+		addSource(detected[index].source, result)
 	}
-	
-	// Copy the executable to the install path
-	destPath := filepath.Join(installPath, "rx")
-	src, err := os.Open(exePath)
-	if err != nil {
-		return fmt.Errorf("failed to open source file: %w", err)
+
+	// Only a project whose sources have no scripts falls back to scripts/,
+	// including one with a Makefile of no targets
+	if len(sources) == 0 {
+		for index, result := range listSources(lastResort, wait, opts.Profile) {
+			addSource(lastResort[index].source, result)
+		}
 	}
-	defer src.Close()
-	
-	dst, err := os.OpenFile(destPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
-	if err != nil {
-		return fmt.Errorf("failed to create destination file: %w", err)
+
+	if opts.Source != "" && len(failures) > 0 {
+		return nil, nil, fmt.Errorf("--source %s: %w", opts.Source, failures[0])
 	}
-	defer dst.Close()
-	
-	if _, err := io.Copy(dst, src); err != nil {
-		return fmt.Errorf("failed to copy file: %w", err)
+
+	if len(sources) == 0 {
+		// A broken file or a stuck command is the problem to fix, not a
+		// missing source
+		var broken []error
+		for _, failure := range failures {
+			var fileErr *sourceFileError
+			if errors.As(failure, &fileErr) || errors.Is(failure, errListTimeout) {
+				broken = append(broken, failure)
+			}
+		}
+		if len(broken) > 0 {
+			return nil, nil, errors.Join(broken...)
+		}
+		if len(failures) > 0 {
+			return nil, nil, fmt.Errorf("%w: %w", errNoSource, errors.Join(failures...))
+		}
+		return nil, nil, errNoSource
 	}
-	
-	return nil
+
+	// Report sources that were found but failed, so a broken package.json
+	// isn't silently hidden behind a working Makefile
+	if opts.Warn != nil {
+		for _, failure := range failures {
+			opts.Warn(failure)
+		}
+	}
+
+	markDuplicates(items)
+	return sources, items, nil
 }
 
-// updateShellConfig adds the install path to PATH if not already there
-func updateShellConfig(shellConfigPath, installPath string) error {
-	// Read the current shell config
-	content, err := os.ReadFile(shellConfigPath)
-	if err != nil {
-		return fmt.Errorf("failed to read shell config: %w", err)
+// markDuplicates flags the items whose name is also used by an item of
+// another source
+func markDuplicates(items []list.Item) {
+	sourcesByName := make(map[string]map[string]bool)
+	for _, listItem := range items {
+		if i, ok := listItem.(item); ok {
+			if sourcesByName[i.name] == nil {
+				sourcesByName[i.name] = make(map[string]bool)
+			}
+			sourcesByName[i.name][i.source] = true
+		}
 	}
-	
-	// Check if the path is already in the config
-	pathLine := fmt.Sprintf("export PATH=\"$PATH:%s\"", installPath)
-	if strings.Contains(string(content), installPath) {
-		return nil // Path already in config
+	for n, listItem := range items {
+		if i, ok := listItem.(item); ok && len(sourcesByName[i.name]) > 1 {
+			i.duplicate = true
+			items[n] = i
+		}
 	}
-	
-	// Append the path to the config
-	f, err := os.OpenFile(shellConfigPath, os.O_APPEND|os.O_WRONLY, 0644)
+}
+
+// sourceNames lists the names of the sources in the order their items appear
+func sourceNames(sources map[string]ScriptSource, items []list.Item) []string {
+	names := []string{}
+	seen := make(map[string]bool)
+	for _, listItem := range items {
+		i, ok := listItem.(item)
+		if !ok || seen[i.source] {
+			continue
+		}
+		seen[i.source] = true
+		if source, ok := sources[i.source]; ok {
+			names = append(names, source.Name())
+		}
+	}
+	return names
+}
+
+func main() {
+	opts, command, err := parseArgs(os.Args[1:])
 	if err != nil {
-		return fmt.Errorf("failed to open shell config: %w", err)
+		fmt.Fprintf(os.Stderr, "rx: %v\n\n", err)
+		printUsage(os.Stderr)
+		os.Exit(2)
 	}
-	defer f.Close()
-	
-	if _, err := f.WriteString("\n# Added by rx init\n" + pathLine + "\n"); err != nil {
-		return fmt.Errorf("failed to update shell config: %w", err)
+
+	// With --print-result stdout carries the result, so the picker is
+	// drawn on stderr
+	outputs := []*os.File{os.Stdout, os.Stderr}
+	if opts.printResult {
+		outputs = outputs[1:]
+		lipgloss.SetDefaultRenderer(lipgloss.NewRenderer(os.Stderr))
+	}
+	if colorDisabled(opts.noColor, outputs...) {
+		disableColor()
 	}
-	
-	return nil
-}
 
-// handleInit handles the init command
-func handleInit() {
-	installPath := getDefaultInstallPath()
-	shellConfigPath := getShellConfigPath()
-	
-	fmt.Println(successStyle.Render("Installing rx to " + installPath))
-	
-	if err := installRx(installPath); err != nil {
-		fmt.Println(errorStyle.Render(fmt.Sprintf("Error installing rx: %v", err)))
+	// Everything below works in the -C directory
+	if err := opts.changeDir(); err != nil {
+		fmt.Fprintf(os.Stderr, "rx: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Handle flags that don't need a script source
+	switch {
+	case opts.help:
+		printUsage(os.Stdout)
+		return
+	case opts.version:
+		fmt.Println(versionString())
+		return
+	case opts.clearHistory:
+		if err := clearHistory(); err != nil {
+			fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Error clearing history: %v", err)))
+			os.Exit(1)
+		}
+		fmt.Println(successStyle.Render("History cleared"))
 		return
 	}
-	
-	if shellConfigPath != "" {
-		fmt.Println(successStyle.Render("Updating shell config at " + shellConfigPath))
-		if err := updateShellConfig(shellConfigPath, installPath); err != nil {
-			fmt.Println(errorStyle.Render(fmt.Sprintf("Error updating shell config: %v", err)))
+
+	// rx -1 <query> is rx run with fuzzy names
+	if opts.firstMatch && (len(command) == 0 || command[0] != "run") {
+		command = append([]string{"run"}, command...)
+	}
+
+	// Handle subcommands
+	if len(command) > 0 {
+		switch command[0] {
+		case "init":
+			handleInit(opts)
+			return
+		case "uninstall":
+			handleUninstall(opts)
+			return
+		case "run", "stats":
+			// Handled below once the config is loaded
+		case "completion":
+			if len(command) != 2 {
+				fmt.Fprintln(os.Stderr, "usage: rx completion bash|zsh|fish")
+				os.Exit(2)
+			}
+			if err := writeCompletion(os.Stdout, command[1]); err != nil {
+				fmt.Fprintf(os.Stderr, "rx: %v\n", err)
+				os.Exit(2)
+			}
+			return
+		case "help":
+			printUsage(os.Stdout)
 			return
+		case "version":
+			fmt.Println(versionString())
+			return
+		default:
+			fmt.Fprintf(os.Stderr, "rx: unknown command %q\n\n", command[0])
+			printUsage(os.Stderr)
+			os.Exit(2)
 		}
 	}
 	
-	fmt.Println(successStyle.Render("\nrx has been installed successfully!"))
-	fmt.Println("To use rx from any directory, restart your terminal or run:")
-	fmt.Printf("  source %s\n", shellConfigPath)
-}
+	// Load the config file before building the model
+	start := time.Now()
+	cfg, warnings := loadConfig()
+	opts.profile.track("config", start)
+	for _, warning := range warnings {
+		fmt.Fprintln(os.Stderr, "rx: warning: "+warning)
+	}
+	applyTheme(themes[opts.themeName(cfg)])
 
-// findScriptSource tries to find a suitable script source in the current directory
-func findScriptSource() (ScriptSource, []list.Item, error) {
-	// Try package.json first
-	if _, err := os.Stat("package.json"); err == nil {
-		if _, err := exec.LookPath("npm"); err == nil {
-			npmSource := &NPMScriptSource{}
-			items, err := npmSource.GetScripts()
-			if err == nil {
-				return npmSource, items, nil
-			}
+	// Pick one of the directories rx was recently used in to start there
+	if opts.recentDirs {
+		dir, err := pickRecentDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "rx: %v\n", err)
+			os.Exit(1)
+		}
+		if dir == "" {
+			return
+		}
+		if err := os.Chdir(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "rx: %v\n", err)
+			os.Exit(1)
 		}
 	}
 
-	// Try Makefile next
-	if _, err := os.Stat("Makefile"); err == nil {
-		if _, err := exec.LookPath("make"); err == nil {
-			makeSource := &MakefileScriptSource{}
-			items, err := makeSource.GetScripts()
-			if err == nil {
-				return makeSource, items, nil
-			}
-		}
+	// From a subdirectory, use the scripts of the project above it
+	root, err := opts.findRoot(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rx: %v\n", err)
+		os.Exit(1)
 	}
 
-	return nil, nil, fmt.Errorf("no valid script source found")
-}
+	if len(command) > 0 && command[0] == "run" {
+		handleRun(opts, cfg, command[1:])
+		return
+	}
 
-func main() {
-	// Check if this is the init command
-	if len(os.Args) > 1 && os.Args[1] == "init" {
-		handleInit()
+	cwd, _ := os.Getwd()
+
+	if len(command) > 0 && command[0] == "stats" {
+		if err := handleStats(os.Stdout, cwd, opts.json); err != nil {
+			fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+			os.Exit(1)
+		}
 		return
 	}
-	
-	// Find a suitable script source
-	source, items, err := findScriptSource()
-	if err != nil {
-		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
-		fmt.Println("No package.json or Makefile found in the current directory.")
+
+	// Print the scripts instead of starting the TUI
+	if opts.list {
+		_, items, err := findScriptSources(opts.sourceOptions(cfg))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+			printNoSourceHint(err)
+			os.Exit(1)
+		}
+
+		// Order the list, by default scripts recently run in this
+		// directory come first
+		start := time.Now()
+		items = sortItems(items, opts.sortOrder(cfg), cwd)
+		opts.profile.track("sort", start)
+		if err := handleList(os.Stdout, items, opts.json); err != nil {
+			fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+			os.Exit(1)
+		}
+		opts.profile.print(os.Stderr)
 		return
 	}
 
-	// Setup list with custom styling
-	delegate := list.NewDefaultDelegate()
-	delegate.Styles.SelectedTitle = delegate.Styles.SelectedTitle.Foreground(lipgloss.Color("#61AFEF")).Bold(true)
-	delegate.Styles.SelectedDesc = delegate.Styles.SelectedDesc.Foreground(lipgloss.Color("#98C379"))
+	// Read the .env files for the summary of --confirm-all and to run the
+	// script with, again if the no source screen moves to the parent
+	dotenv := opts.dotenv()
 
-	l := list.New(items, delegate, 0, 0)
-	l.Title = fmt.Sprintf("Available scripts from %s", source.Name())
+	// Setup list with custom styling, grouping items under a header for
+	// each source. Items are added once the sources are enumerated.
+	delegate := newItemDelegate(activeTheme)
+	delegate.MaxDescription = cfg.DescriptionLength
+	applyColors(cfg.Colors, &delegate)
+
+	keys := keysFor(cfg.Keys)
+	l := list.New(nil, delegate, 0, 0)
+	l.Title = "Available scripts"
 	l.SetShowStatusBar(false)
 	l.Styles.Title = titleStyle
+	keys.applyTo(&l.KeyMap)
+
+	// The filter starts focused unless the config says otherwise
+	filterFocused := true
+	if cfg.FilterFocused != nil {
+		filterFocused = *cfg.FilterFocused
+	}
+	if opts.selectFirst {
+		filterFocused = false
+	}
 
-	// Initialize our model
+	// Initialize our model, the scripts are loaded by Init
 	m := model{
 		list:          l,
-		allItems:      items,
-		filterFocused: true,
-		source:        source,
+		filterFocused: filterFocused,
+		extraArgs:     opts.extraArgs,
+		dryRun:        opts.dryRun,
+		autoRun:       opts.autoRun,
+		keys:          keys,
+		printResult:   opts.printResult,
+		parallel:      opts.parallel,
+		dir:           cwd,
+		root:          root,
+		loading:       true,
+		load: func(dir string) tea.Cmd {
+			return loadScripts(opts.sourceOptions(cfg), opts.sortOrder(cfg), dir)
+		},
+		spinner: spinner.New(spinner.WithSpinner(spinner.Dot), spinner.WithStyle(previewStyle)),
+
+		itemHeight:      delegate.Height(),
+		itemSpacing:     delegate.Spacing(),
+		confirmPatterns: cfg.confirmPatterns(),
+		confirmAll:      opts.confirmAll || cfg.ConfirmAll,
+		dotenv:          dotenv,
+		readDotenv:      opts.readDotenv,
+		envDefaults:     cfg.Env,
+		flagDefaults:    cfg.Flags,
+		profile:         opts.profile,
+		started:         time.Now(),
 	}
 
 	// Create the filter form with Huh
-	m.form = huh.NewForm(
-		huh.NewGroup(
-			huh.NewInput().
-				Title("Filter").
-				Placeholder("Type to filter scripts...").
-				Value(&m.filterInput).
-				Validate(func(s string) error {
-					m.filterInput = s
-					m.applyFilter()
-					return nil // Always valid
-				}).
-				Key("filter"),
-		),
-	).WithShowHelp(false).WithShowErrors(false)
+	m.form, m.filterField = newFilterForm()
+
+	// Start with the filter rx was last left with here
+	if cfg.RememberFilter {
+		if filter, err := loadFilter(cwd); err == nil && filter != "" {
+			m.filterInput = filter
+			m.filterField.Value(&filter)
+			m.restored = true
+		}
+	}
 
 	// Start the Bubble Tea program
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	programOptions := []tea.ProgramOption{tea.WithAltScreen()}
+	if opts.mouseEnabled(cfg) {
+		programOptions = append(programOptions, tea.WithMouseCellMotion())
+	}
+	if opts.printResult {
+		programOptions = append(programOptions, tea.WithOutput(os.Stderr))
+	}
+	// Signals quit through the model, so the terminal is restored first
+	programOptions = append(programOptions, tea.WithoutSignalHandler())
+	p := tea.NewProgram(m, programOptions...)
+	stopSignals := forwardSignals(p)
 	finalModel, err := p.Run()
+	stopSignals()
 	if err != nil {
 		fmt.Println(errorStyle.Render(fmt.Sprintf("Error running program: %v", err)))
 		return
 	}
+	opts.profile.print(os.Stderr)
 
-	// If a script was selected, run it
-	if m, ok := finalModel.(model); ok && m.selected != "" {
-		// Verify the selected item is valid
-		_, ok := m.list.SelectedItem().(item)
-		if !ok {
-			fmt.Println(errorStyle.Render("Error: Could not determine script source"))
-			return
+	m, _ = finalModel.(model)
+	if m.signal != nil {
+		os.Exit(signalExitCode(m.signal))
+	}
+
+	// The no source screen may have moved rx to the parent directory,
+	// which scripts run in and are recorded under
+	cwd, _ = os.Getwd()
+	if cfg.RememberFilter && m.loadErr == nil {
+		if err := saveFilter(cwd, m.filterInput); err != nil {
+			fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Warning: could not save the filter: %v", err)))
+		}
+	}
+
+	// Sources that failed to load are reported once the terminal is back
+	for _, warning := range m.warnings {
+		fmt.Fprintln(os.Stderr, "rx: warning: "+warning.Error())
+	}
+
+	// Describe the chosen scripts instead of running them
+	if opts.printResult {
+		if m.loadErr != nil {
+			fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Error: %v", m.loadErr)))
+			os.Exit(1)
+		}
+		for _, i := range m.selected {
+			if err := recordHistory(cwd, i.name, i.source); err != nil {
+				fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Warning: could not save history: %v", err)))
+			}
+			if err := recordStats(cwd, i.name, i.source); err != nil {
+				fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Warning: could not save stats: %v", err)))
+			}
+		}
+		if err := writeResult(os.Stdout, m.sources, m.selected, m.extraArgs, m.env, cwd, m.parallel); err != nil {
+			fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+			os.Exit(1)
+		}
+		return
+	}
+
+	if m.loadErr != nil {
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", m.loadErr)))
+		printNoSourceHint(m.loadErr)
+		os.Exit(1)
+	}
+
+	// If scripts were selected, run them
+	if len(m.selected) > 0 {
+		// Look up the sources the selected items came from
+		for _, i := range m.selected {
+			if _, ok := m.sources[i.source]; !ok {
+				fmt.Println(errorStyle.Render("Error: Could not determine script source"))
+				return
+			}
 		}
 		
+		// Print the commands instead of running them
+		if m.dryRun {
+			for _, i := range m.selected {
+				if err := printCommand(os.Stdout, m.sources[i.source], i.name, m.extraArgs, m.env); err != nil {
+					fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+					os.Exit(1)
+				}
+			}
+			return
+		}
+
+		if opts.watch && len(m.selected) > 1 {
+			fmt.Println(errorStyle.Render("Error: --watch runs a single script"))
+			os.Exit(1)
+		}
+
+		// Remember the scripts before exec replaces the process
+		for _, i := range m.selected {
+			if err := recordHistory(cwd, i.name, i.source); err != nil {
+				fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Warning: could not save history: %v", err)))
+			}
+			if err := recordStats(cwd, i.name, i.source); err != nil {
+				fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Warning: could not save stats: %v", err)))
+			}
+		}
+		if cfg.Log {
+			if err := logRuns(cwd, m.sources, m.selected, m.extraArgs); err != nil {
+				fmt.Fprintln(os.Stderr, errorStyle.Render(fmt.Sprintf("Warning: could not write the run log: %v", err)))
+			}
+		}
+
+		// Variables entered in the prompt override .env files
+		env := append(m.dotenv, m.env...)
+
+		// A queue runs each script as a child process, in turn or all at
+		// once with --parallel
+		if len(m.selected) > 1 {
+			if opts.parallel {
+				os.Exit(runParallel(m.sources, m.selected, m.extraArgs, env))
+			}
+			os.Exit(runSequence(m.sources, m.selected, m.extraArgs, env))
+		}
+		selected := m.selected[0]
+		source := m.sources[selected.source]
+
+		// Rerun the script on changes instead of replacing rx with it
+		if opts.watch {
+			if err := watchScript(source, selected.name, m.extraArgs, env, opts.watchGlobs); err != nil {
+				fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+				os.Exit(1)
+			}
+			return
+		}
+
+		// Leave rx's shell free by running the script in a new tmux pane
+		if mode := opts.tmuxMode(cfg); mode != "" {
+			if err := runInTmux(mode, source, selected.name, m.extraArgs, env); err != nil {
+				fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+				os.Exit(1)
+			}
+			fmt.Println(successStyle.Render(fmt.Sprintf("Running %s in a new tmux %s", selected.name, mode)))
+			return
+		}
+
 		// Run the script using the appropriate source
-		fmt.Println(successStyle.Render(fmt.Sprintf("Running: %s", m.selected)))
+		fmt.Println(successStyle.Render(fmt.Sprintf("Running: %s", selected.name)))
 		
-		err := m.source.RunScript(m.selected)
-		if err != nil {
-			fmt.Println(errorStyle.Render(fmt.Sprintf("Error executing script: %v", err)))
-		}
-		// If syscall.Exec succeeds, the program will be replaced, so we won't reach here
+		// A successful run replaces rx or exits with the script's exit code,
+		// so we only get here when the script couldn't be started
+		err := source.RunScript(selected.name, m.extraArgs, env)
+		fmt.Println(errorStyle.Render(fmt.Sprintf("Error executing script: %v", err)))
+		os.Exit(1)
 	}
 }
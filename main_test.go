@@ -0,0 +1,2046 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestParseMakefileTargets(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{
+			name:   "simple targets",
+			output: "# Files\nbuild: deps\n\tgo build ./...\n\ntest:\n\tgo test ./...\n",
+			want:   []string{"build", "test"},
+		},
+		{
+			name:   "targets with slashes",
+			output: "# Files\nbuild/linux: main.go\n\tGOOS=linux go build\n\nbuild/darwin:\n",
+			want:   []string{"build/darwin", "build/linux"},
+		},
+		{
+			name:   "dots in the middle",
+			output: "# Files\ndocs.html: docs.md\n\tpandoc docs.md -o docs.html\n\nv1.2-release:\n",
+			want:   []string{"docs.html", "v1.2-release"},
+		},
+		{
+			name:   "phony declarations are not targets",
+			output: "# Files\n.PHONY: build test\n\nbuild:\n\ntest:\n\n.DEFAULT:\n",
+			want:   []string{"build", "test"},
+		},
+		{
+			name:   "phony targets win over file targets",
+			output: "# Files\n.PHONY: build clean\n\nbuild: main.o\n\nmain.o: main.c\n\nclean:\n",
+			want:   []string{"build", "clean"},
+		},
+		{
+			name:   "suffix and pattern rules",
+			output: "# Files\n.c.o:\n\t$(CC) -c $<\n\n%.o: %.c\n\t$(CC) -c $<\n\nall: main.o\n",
+			want:   []string{"all"},
+		},
+		{
+			name:   "variable assignments",
+			output: "# Files\nCC := gcc\nFLAGS = -O2\nall:\n",
+			want:   []string{"all"},
+		},
+		{
+			name:   "files that are not targets",
+			output: "# Files\n# Not a target:\nmain.go:\n#  Implicit rule search has not been done.\n\nbuild: main.go\n",
+			want:   []string{"build"},
+		},
+		{
+			name:   "multiple targets on one line",
+			output: "# Files\nbuild test: deps\n\tgo $@ ./...\n\ndeps:\n",
+			want:   []string{"build", "deps", "test"},
+		},
+		{
+			name:   "targets with prerequisites",
+			output: "# Files\nrelease: build test docs/index.html\n\t./release.sh\n\nbuild:\n",
+			want:   []string{"build", "release"},
+		},
+		{
+			name:   "double-colon rules",
+			output: "# Files\nclean:: \n\trm -rf dist\n\nclean::\n\trm -rf tmp\n\ninstall:: build\n",
+			want:   []string{"clean", "install"},
+		},
+		{
+			name:   "recipe lines with colons",
+			output: "# Files\ndeploy:\n\tscp dist/app host:/srv/app\n\techo \"status: done\"\n  ssh host: restart\n",
+			want:   []string{"deploy"},
+		},
+		{
+			name:   "comment lines",
+			output: "# Files\n# build: not a rule\n#  Last modified 2024-01-01 12:00:00\nbuild:\n# recipe to execute (from 'Makefile', line 2):\n",
+			want:   []string{"build"},
+		},
+		{
+			name:   "dry-run output before the database",
+			output: "echo done: ok\n# Variables\nMAKEFILE_LIST := Makefile\n\n# Files\nbuild:\n\techo done: ok\n",
+			want:   []string{"build"},
+		},
+		{
+			name:   "assignments and target-specific variables",
+			output: "# Files\nVAR := value\nURL = http://host:80\ntest: VERBOSE = 1\ntest:\n\tgo test\n\nbuild: dep\nlint: ; echo a=b\n",
+			want:   []string{"build", "lint", "test"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseMakefileTargets(tt.output, false)
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMakefileTargets() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMakefileTargetsIncludeInternal(t *testing.T) {
+	output := "# Files\n.PHONY: build\n\n.init:\n\techo init\n\nbuild:\n\ndist/app: main.go\n"
+	got := parseMakefileTargets(output, true)
+	sort.Strings(got)
+	want := []string{".PHONY", ".init", "build", "dist/app"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMakefileTargets(includeInternal) = %q, want %q", got, want)
+	}
+}
+
+func TestIsMakeAssignment(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"VAR = value", true},
+		{"VAR := value", true},
+		{"VAR ::= value", true},
+		{"VAR :::= value", true},
+		{"VAR ?= value", true},
+		{"VAR += value", true},
+		{"VAR != date", true},
+		{"override VAR := value", true},
+		{"URL = http://host:80", true},
+		{"TARGET: dep", false},
+		{"TARGET:: dep", false},
+		{"TARGET: VAR=val", false},
+		{"TARGET: VAR := val", false},
+		{"TARGET: ; echo a=b", false},
+	}
+
+	for _, tt := range tests {
+		if got := isMakeAssignment(tt.line); got != tt.want {
+			t.Errorf("isMakeAssignment(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseMakefilePhony(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   map[string]bool
+	}{
+		{
+			name:   "no phony declarations",
+			output: "build:\n\ttrue\n",
+			want:   map[string]bool{},
+		},
+		{
+			name:   "single declaration",
+			output: ".PHONY: build test\n",
+			want:   map[string]bool{"build": true, "test": true},
+		},
+		{
+			name:   "multiple declarations accumulate",
+			output: ".PHONY: build\nbuild:\n\tgo build\n.PHONY: test lint\ntest:\n",
+			want:   map[string]bool{"build": true, "test": true, "lint": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseMakefilePhony(tt.output)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMakefilePhony() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNPMScriptsKeepDeclarationOrder(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join("testdata", "npm-order")); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	source := &NPMScriptSource{Manager: "npm"}
+	items, err := source.GetScripts()
+	if err != nil {
+		t.Fatalf("GetScripts() error = %v", err)
+	}
+
+	got := []string{}
+	for _, listItem := range items {
+		got = append(got, listItem.(item).name)
+	}
+	want := []string{"setup", "lint", "build", "test", "deploy", "clean"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetScripts() names = %q, want %q", got, want)
+	}
+}
+
+func TestRemoveShellConfigBlock(t *testing.T) {
+	const installPath = "/home/me/.local/bin"
+	tests := []struct {
+		name        string
+		content     string
+		want        string
+		wantRemoved bool
+	}{
+		{
+			name:        "block added by init",
+			content:     "alias ll='ls -l'\n\n# Added by rx init\nexport PATH=\"$PATH:/home/me/.local/bin\"\n",
+			want:        "alias ll='ls -l'\n",
+			wantRemoved: true,
+		},
+		{
+			name:        "block in the middle",
+			content:     "a=1\n\n# Added by rx init\nexport PATH=\"$PATH:/home/me/.local/bin\"\nb=2\n",
+			want:        "a=1\nb=2\n",
+			wantRemoved: true,
+		},
+		{
+			name:    "no block",
+			content: "export PATH=\"$PATH:/home/me/.local/bin\"\n",
+			want:    "export PATH=\"$PATH:/home/me/.local/bin\"\n",
+		},
+		{
+			name:    "marker with a different path line",
+			content: "\n# Added by rx init\nexport PATH=\"$PATH:/opt/bin\"\n",
+			want:    "\n# Added by rx init\nexport PATH=\"$PATH:/opt/bin\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, removed := removeShellConfigBlock(tt.content, shellPathLine("/home/me/.zshrc", installPath))
+			if got != tt.want || removed != tt.wantRemoved {
+				t.Errorf("removeShellConfigBlock() = %q, %v, want %q, %v", got, removed, tt.want, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestHasShellConfigBlock(t *testing.T) {
+	const pathLine = "export PATH=\"$PATH:/home/me/.local/bin\""
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{
+			name:    "block added by init",
+			content: "alias ll='ls -l'\n\n# Added by rx init\n" + pathLine + "\n",
+			want:    true,
+		},
+		{
+			name:    "path mentioned elsewhere",
+			content: "# /home/me/.local/bin holds my scripts\nexport GOBIN=/home/me/.local/bin\n",
+			want:    false,
+		},
+		{
+			name:    "path line without the marker",
+			content: pathLine + "\n",
+			want:    false,
+		},
+		{
+			name:    "marker with a different path line",
+			content: "# Added by rx init\nexport PATH=\"$PATH:/opt/bin\"\n",
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasShellConfigBlock(tt.content, pathLine); got != tt.want {
+				t.Errorf("hasShellConfigBlock() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateShellConfigIdempotent(t *testing.T) {
+	const installPath = "/home/me/.local/bin"
+	tests := []struct {
+		name     string
+		config   string
+		existing string
+		want     string
+	}{
+		{
+			name:     "zsh",
+			config:   ".zshrc",
+			existing: "export GOBIN=/home/me/.local/bin\n",
+			want:     "export GOBIN=/home/me/.local/bin\n\n# Added by rx init\nexport PATH=\"$PATH:/home/me/.local/bin\"\n",
+		},
+		{
+			name:   "fish without a config",
+			config: filepath.Join(".config", "fish", "config.fish"),
+			want:   "\n# Added by rx init\nfish_add_path \"/home/me/.local/bin\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.config)
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for i := 0; i < 2; i++ {
+				if err := updateShellConfig(path, installPath); err != nil {
+					t.Fatalf("updateShellConfig() error = %v", err)
+				}
+			}
+
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("config after two updates = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake executables are shell scripts")
+	}
+
+	// Put fake executables on PATH so the lookups succeed
+	bin := t.TempDir()
+	for _, name := range []string{"npm", "yarn", "pnpm", "make"} {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin)
+
+	tests := []struct {
+		name      string
+		source    ScriptSource
+		script    string
+		extraArgs []string
+		want      []string
+	}{
+		{
+			name:   "npm script",
+			source: &NPMScriptSource{Manager: "npm"},
+			script: "build",
+			want:   []string{"npm", "run", "build"},
+		},
+		{
+			name:      "npm script with extra args",
+			source:    &NPMScriptSource{Manager: "npm"},
+			script:    "test",
+			extraArgs: []string{"--watch", "src/a b.ts"},
+			want:      []string{"npm", "run", "test", "--", "--watch", "src/a b.ts"},
+		},
+		{
+			name:   "yarn script",
+			source: &NPMScriptSource{Manager: "yarn"},
+			script: "lint",
+			want:   []string{"yarn", "run", "lint"},
+		},
+		{
+			name:   "built-in command",
+			source: &NPMScriptSource{Manager: "npm", builtins: map[string]bool{"install": true}},
+			script: "install",
+			want:   []string{"npm", "install"},
+		},
+		{
+			name: "workspace script",
+			source: &NPMScriptSource{Manager: "pnpm", workspaceScripts: map[string]workspaceScript{
+				"web/build": {Workspace: "web", Script: "build"},
+			}},
+			script: "web/build",
+			want:   []string{"pnpm", "--filter", "web", "run", "build"},
+		},
+		{
+			name:   "make target",
+			source: &MakefileScriptSource{},
+			script: "build",
+			want:   []string{"make", "build"},
+		},
+		{
+			name:      "make target with extra args",
+			source:    &MakefileScriptSource{},
+			script:    "test",
+			extraArgs: []string{"VERBOSE=1"},
+			want:      []string{"make", "test", "VERBOSE=1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, args, err := tt.source.ResolveCommand(tt.script, tt.extraArgs)
+			if err != nil {
+				t.Fatalf("ResolveCommand() error = %v", err)
+			}
+			if want := filepath.Join(bin, tt.want[0]); path != want {
+				t.Errorf("ResolveCommand() path = %q, want %q", path, want)
+			}
+			if !reflect.DeepEqual(args, tt.want) {
+				t.Errorf("ResolveCommand() args = %q, want %q", args, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveCommandMissingExecutable(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	for _, source := range []ScriptSource{&NPMScriptSource{Manager: "npm"}, &MakefileScriptSource{}} {
+		if _, _, err := source.ResolveCommand("build", nil); err == nil {
+			t.Errorf("%T.ResolveCommand() error = nil, want an error", source)
+		}
+	}
+}
+
+func TestNPMHooks(t *testing.T) {
+	tests := []struct {
+		name    string
+		scripts []string
+		want    map[string]bool
+	}{
+		{
+			name:    "pre and post of an existing script",
+			scripts: []string{"prebuild", "build", "postbuild", "test"},
+			want:    map[string]bool{"prebuild": true, "postbuild": true},
+		},
+		{
+			name:    "hook without its script",
+			scripts: []string{"predeploy", "build"},
+			want:    map[string]bool{},
+		},
+		{
+			name:    "hook of a hook",
+			scripts: []string{"preprebuild", "prebuild", "build"},
+			want:    map[string]bool{"preprebuild": true, "prebuild": true},
+		},
+		{
+			name:    "bare prefixes",
+			scripts: []string{"pre", "post", "prepare", "posture"},
+			want:    map[string]bool{},
+		},
+		{
+			name:    "colon names",
+			scripts: []string{"pretest:unit", "test:unit", "posttest:e2e"},
+			want:    map[string]bool{"pretest:unit": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := npmHooks(tt.scripts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("npmHooks(%q) = %v, want %v", tt.scripts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNPMScriptSourceShowHooks(t *testing.T) {
+	dir := t.TempDir()
+	packageJSON := `{"scripts": {"prebuild": "rm -rf dist", "build": "tsc", "postbuild": "cp README.md dist", "predeploy": "echo"}}`
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(packageJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	tests := []struct {
+		showHooks bool
+		want      []string
+	}{
+		{showHooks: false, want: []string{"build", "predeploy"}},
+		{showHooks: true, want: []string{"prebuild", "build", "postbuild", "predeploy"}},
+	}
+	for _, tt := range tests {
+		source := &NPMScriptSource{Manager: "npm", ShowHooks: tt.showHooks}
+		items, err := source.GetScripts()
+		if err != nil {
+			t.Fatalf("GetScripts() error = %v", err)
+		}
+		got := []string{}
+		for _, listItem := range items {
+			got = append(got, listItem.(item).name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GetScripts() with ShowHooks %v = %q, want %q", tt.showHooks, got, tt.want)
+		}
+	}
+}
+
+func TestJSONKeyLine(t *testing.T) {
+	packageJSON := `{
+  "name": "build",
+  "scripts": {
+    "build": "tsc",
+    "test": "jest"
+  },
+  "test": true
+}`
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{key: "build", want: 4},
+		{key: "test", want: 5},
+		{key: "missing", want: 3},
+	}
+	for _, tt := range tests {
+		if got := jsonKeyLine([]byte(packageJSON), "scripts", tt.key); got != tt.want {
+			t.Errorf("jsonKeyLine(%q) = %d, want %d", tt.key, got, tt.want)
+		}
+	}
+
+	if got := jsonKeyLine([]byte(`{"name": "x"}`), "scripts", "build"); got != 0 {
+		t.Errorf("jsonKeyLine() without scripts = %d, want 0", got)
+	}
+}
+
+func TestMakefileTargetLine(t *testing.T) {
+	makefile := `BUILD := build: not a rule
+# test: commented out
+.PHONY: build test
+
+build test: deps
+	echo test: recipe
+
+lint::
+	golint
+`
+	tests := []struct {
+		target string
+		want   int
+	}{
+		{target: "build", want: 5},
+		{target: "test", want: 5},
+		{target: "lint", want: 8},
+		{target: "deps", want: 0},
+		{target: "BUILD", want: 0},
+	}
+	for _, tt := range tests {
+		if got := makefileTargetLine([]byte(makefile), tt.target); got != tt.want {
+			t.Errorf("makefileTargetLine(%q) = %d, want %d", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestWatchMatches(t *testing.T) {
+	tests := []struct {
+		globs []string
+		path  string
+		want  bool
+	}{
+		{globs: nil, path: "src/app.ts", want: true},
+		{globs: []string{"*.ts"}, path: "src/app.ts", want: true},
+		{globs: []string{"*.ts"}, path: "src/app.js", want: false},
+		{globs: []string{"src/*.ts"}, path: "src/app.ts", want: true},
+		{globs: []string{"src/*.ts"}, path: "lib/src/app.ts", want: false},
+		{globs: []string{"*.go", "go.mod"}, path: "go.mod", want: true},
+	}
+	for _, tt := range tests {
+		if got := watchMatches(tt.globs, tt.path); got != tt.want {
+			t.Errorf("watchMatches(%q, %q) = %v, want %v", tt.globs, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestIsWatchSkipped(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "src/app.ts", want: false},
+		{path: "node_modules/pkg/index.js", want: true},
+		{path: ".git/index", want: true},
+		{path: "src/.app.ts.swp", want: true},
+		{path: "src/app.ts~", want: true},
+		{path: "dist", want: true},
+	}
+	for _, tt := range tests {
+		if got := isWatchSkipped(tt.path); got != tt.want {
+			t.Errorf("isWatchSkipped(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var out strings.Builder
+	var mu sync.Mutex
+	w := &prefixWriter{w: &out, prefix: "lint | ", mu: &mu}
+
+	for _, chunk := range []string{"one\ntw", "o\n", "\nthree"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := out.String(), "lint | one\nlint | two\nlint | \n"; got != want {
+		t.Errorf("before Flush got %q, want %q", got, want)
+	}
+
+	w.Flush()
+	if got, want := out.String(), "lint | one\nlint | two\nlint | \nlint | three\n"; got != want {
+		t.Errorf("after Flush got %q, want %q", got, want)
+	}
+}
+
+func TestCompletionFlagsParse(t *testing.T) {
+	values := map[string]string{"source": "make", "sort": "name", "watch-glob": "*.go"}
+	for _, flag := range completionFlags {
+		args := []string{"--" + flag.long}
+		if flag.value {
+			args = append(args, values[flag.long])
+		}
+		if _, _, err := parseArgs(args); err != nil {
+			t.Errorf("completion offers --%s, but parseArgs(%q) failed: %v", flag.long, args, err)
+		}
+	}
+}
+
+func TestWriteCompletionUnsupportedShell(t *testing.T) {
+	var out strings.Builder
+	if err := writeCompletion(&out, "powershell"); err == nil {
+		t.Error("writeCompletion(powershell) succeeded, want an error")
+	}
+}
+
+func TestFindProjectRoot(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	// repo/.git, repo/package.json, repo/src/components, and a repository
+	// nested in it without scripts
+	repo, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{".git", "src/components", "vendor/lib/.git", "vendor/lib/src"} {
+		if err := os.MkdirAll(filepath.Join(repo, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(repo, "package.json"), []byte(`{"scripts": {"build": "tsc"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		start string
+		tag   string
+		want  string
+	}{
+		{start: "src/components", want: repo},
+		{start: ".", want: ""},
+		{start: "vendor/lib/src", want: ""},
+		{start: "src/components", tag: "make", want: ""},
+	}
+	for _, tt := range tests {
+		start := filepath.Join(repo, tt.start)
+		if err := os.Chdir(start); err != nil {
+			t.Fatal(err)
+		}
+		got, err := findProjectRoot(sourceOptions{Source: tt.tag})
+		if err != nil {
+			t.Fatalf("findProjectRoot() from %s error = %v", tt.start, err)
+		}
+		if got != tt.want {
+			t.Errorf("findProjectRoot(%q) from %s = %q, want %q", tt.tag, tt.start, got, tt.want)
+		}
+
+		// The working directory is the root found, or where it started
+		wantDir := start
+		if tt.want != "" {
+			wantDir = tt.want
+		}
+		if dir, _ := os.Getwd(); dir != wantDir {
+			t.Errorf("findProjectRoot(%q) from %s left the working directory at %s, want %s", tt.tag, tt.start, dir, wantDir)
+		}
+	}
+}
+
+func TestParseEnv(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{input: "", want: nil},
+		{input: "PORT=3000", want: []string{"PORT=3000"}},
+		{input: `NODE_ENV=production MESSAGE="hello world" EMPTY=`, want: []string{"NODE_ENV=production", "MESSAGE=hello world", "EMPTY="}},
+		{input: "URL=http://x?a=b", want: []string{"URL=http://x?a=b"}},
+		{input: "PORT", wantErr: true},
+		{input: "=3000", wantErr: true},
+		{input: "1PORT=3000", wantErr: true},
+		{input: `PORT="3000`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseEnv(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseEnv(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseEnv(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestMergeEnv(t *testing.T) {
+	base := []string{"HOME=/home/me", "PORT=80", "PATH=/bin"}
+	got := mergeEnv(base, []string{"PORT=3000", "NODE_ENV=test"})
+	want := []string{"HOME=/home/me", "PATH=/bin", "PORT=3000", "NODE_ENV=test"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeEnv() = %q, want %q", got, want)
+	}
+
+	got = mergeEnv(base, []string{"PORT=1", "DEBUG=1", "PORT=2"})
+	want = []string{"HOME=/home/me", "PATH=/bin", "DEBUG=1", "PORT=2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeEnv() with a variable set twice = %q, want %q", got, want)
+	}
+
+	if got := mergeEnv(base, nil); !reflect.DeepEqual(got, base) {
+		t.Errorf("mergeEnv() without overrides = %q, want %q", got, base)
+	}
+}
+
+func TestParseDotenv(t *testing.T) {
+	dotenv := `# Database
+DB_HOST=localhost
+export DB_PORT=5432
+
+DB_NAME = app # the app database
+PASSWORD="p#ss \"word\"\nnext"
+RAW='single $quoted # not a comment'
+EMPTY=
+URL=http://localhost:3000/#anchor
+`
+	want := []string{
+		"DB_HOST=localhost",
+		"DB_PORT=5432",
+		"DB_NAME=app",
+		"PASSWORD=p#ss \"word\"\nnext",
+		"RAW=single $quoted # not a comment",
+		"EMPTY=",
+		"URL=http://localhost:3000/#anchor",
+	}
+	got, err := parseDotenv(dotenv)
+	if err != nil {
+		t.Fatalf("parseDotenv() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDotenv() = %q, want %q", got, want)
+	}
+
+	for _, invalid := range []string{"NO_EQUALS", "1ABC=x", `QUOTE="open`, "SINGLE='open"} {
+		if _, err := parseDotenv(invalid); err == nil {
+			t.Errorf("parseDotenv(%q) succeeded, want an error", invalid)
+		}
+	}
+}
+
+func TestLoadDotenv(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".env":       "FROM_FILE=env\nLOCAL=env\nRX_TEST_SET=file\n",
+		".env.local": "LOCAL=local\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	t.Setenv("RX_TEST_SET", "environment")
+
+	tests := []struct {
+		override bool
+		want     []string
+	}{
+		{override: false, want: []string{"FROM_FILE=env", "LOCAL=env", "LOCAL=local"}},
+		{override: true, want: []string{"FROM_FILE=env", "LOCAL=env", "RX_TEST_SET=file", "LOCAL=local"}},
+	}
+	for _, tt := range tests {
+		got, err := loadDotenv(tt.override)
+		if err != nil {
+			t.Fatalf("loadDotenv(%v) error = %v", tt.override, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("loadDotenv(%v) = %q, want %q", tt.override, got, tt.want)
+		}
+	}
+}
+
+func TestParseMakefileDescriptions(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "make-descriptions", "Makefile"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := parseMakefileDescriptions(string(data))
+	want := map[string]string{
+		"build":  "Compile the binary",
+		"test":   "Run the tests with the race detector",
+		"lint":   "Check formatting",
+		"fmt":    "Check formatting",
+		"deploy": "Inline wins",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMakefileDescriptions() = %q, want %q", got, want)
+	}
+}
+
+func TestMakefileScriptSourceDescriptions(t *testing.T) {
+	if _, err := exec.LookPath("make"); err != nil {
+		t.Skip("make not installed")
+	}
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join("testdata", "make-descriptions")); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	source := &MakefileScriptSource{NoCache: true}
+	items, err := source.GetScripts()
+	if err != nil {
+		t.Fatalf("GetScripts() error = %v", err)
+	}
+
+	got := map[string]string{}
+	for _, listItem := range items {
+		got[listItem.(item).name] = listItem.(item).description
+	}
+	want := map[string]string{
+		"build":  "Compile the binary",
+		"test":   "Run the tests with the race detector",
+		"lint":   "Check formatting",
+		"clean":  "make target",
+		"deploy": "Inline wins",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetScripts() descriptions = %q, want %q", got, want)
+	}
+}
+
+func TestNPMScriptSourceMalformed(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    string
+	}{
+		{"scripts-array", `package.json:3: "scripts" must be an object of script names and commands, found an array`},
+		{"scripts-string", `package.json:4: "scripts" must be an object of script names and commands, found a string`},
+		{"script-number", `package.json:5: script "port" must be a command string, found a number`},
+		{"trailing-comma", `package.json:5: trailing comma, JSON doesn't allow a comma before } or ]`},
+		{"scripts-info-array", `package.json:6: "scripts-info" must be an object, found an array`},
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			if err := os.Chdir(filepath.Join(wd, "testdata", "npm-malformed", tt.fixture)); err != nil {
+				t.Fatal(err)
+			}
+			defer os.Chdir(wd)
+
+			source := &NPMScriptSource{Manager: "npm"}
+			_, err := source.GetScripts()
+			var fileErr *sourceFileError
+			if !errors.As(err, &fileErr) {
+				t.Fatalf("GetScripts() error = %v, want a sourceFileError", err)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("GetScripts() error = %q, want %q", err, tt.want)
+			}
+
+			// The broken file is reported instead of no source found
+			_, _, err = findScriptSources(sourceOptions{})
+			if err == nil || errors.Is(err, errNoSource) || err.Error() != tt.want {
+				t.Errorf("findScriptSources() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// fakeSource is a ScriptSource whose scripts run echo with their name
+type fakeSource struct {
+	items []list.Item
+}
+
+func (f *fakeSource) Name() string                     { return "fake" }
+func (f *fakeSource) GetScripts() ([]list.Item, error) { return f.items, nil }
+func (f *fakeSource) ResolveCommand(name string, extraArgs []string) (string, []string, error) {
+	return "/bin/echo", append([]string{"echo", name}, extraArgs...), nil
+}
+func (f *fakeSource) RunScript(name string, extraArgs, env []string) error { return nil }
+
+func TestWriteResult(t *testing.T) {
+	sources := map[string]ScriptSource{"fake": &fakeSource{}}
+	build := item{name: "build", source: "fake"}
+	test := item{name: "test", source: "fake"}
+
+	tests := []struct {
+		name  string
+		items []item
+		want  string
+	}{
+		{
+			name: "cancelled",
+			want: `{"cancelled":true}`,
+		},
+		{
+			name:  "one script",
+			items: []item{build},
+			want:  `{"name":"build","source":"fake","command":"echo build --watch","args":["echo","build","--watch"],"env":["PORT=3000"],"cwd":"/project"}`,
+		},
+		{
+			name:  "queue",
+			items: []item{build, test},
+			want:  `{"scripts":[{"name":"build","source":"fake","command":"echo build --watch","args":["echo","build","--watch"],"env":["PORT=3000"],"cwd":"/project"},{"name":"test","source":"fake","command":"echo test --watch","args":["echo","test","--watch"],"env":["PORT=3000"],"cwd":"/project"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := writeResult(&b, sources, tt.items, []string{"--watch"}, []string{"PORT=3000"}, "/project", false); err != nil {
+				t.Fatalf("writeResult() error = %v", err)
+			}
+			var got bytes.Buffer
+			if err := json.Compact(&got, []byte(b.String())); err != nil {
+				t.Fatalf("writeResult() wrote invalid JSON: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("writeResult() = %s, want %s", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkDuplicates(t *testing.T) {
+	items := []list.Item{
+		item{name: "test", source: "npm"},
+		item{name: "build", source: "npm"},
+		item{name: "test", source: "make"},
+		item{name: "lint", source: "make"},
+	}
+	markDuplicates(items)
+
+	got := []string{}
+	for _, listItem := range items {
+		got = append(got, listItem.(item).Title())
+	}
+	want := []string{"test (npm)", "build", "test (make)", "lint"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("titles after markDuplicates() = %q, want %q", got, want)
+	}
+}
+
+func TestListOutputTimeout(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not installed")
+	}
+	start := time.Now()
+	// The child keeps stdout open after sh is killed
+	_, err := listOutput(100*time.Millisecond, "sh", "-c", "sleep 10 & sleep 10")
+	if err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("listOutput() error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("listOutput() returned after %v, want it to stop the command", elapsed)
+	}
+
+	output, err := listOutput(0, "sh", "-c", "echo ok")
+	if err != nil || string(output) != "ok\n" {
+		t.Errorf("listOutput() without a timeout = %q, %v, want %q", output, err, "ok\n")
+	}
+}
+
+func TestTmuxArgs(t *testing.T) {
+	got := tmuxArgs("split", "test", "/src", []string{"npm", "run", "test", "--", "a b"}, []string{"FOO=bar baz"})
+	want := []string{"split-window", "-c", "/src", "-e", "FOO=bar baz", "npm run test -- 'a b'", ";", "set-option", "-p", "remain-on-exit", "on"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tmuxArgs(split) = %q, want %q", got, want)
+	}
+
+	got = tmuxArgs("window", "test", "/src", []string{"make", "test"}, nil)
+	want = []string{"new-window", "-n", "test", "-c", "/src", "make test", ";", "set-option", "-p", "remain-on-exit", "on"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tmuxArgs(window) = %q, want %q", got, want)
+	}
+}
+
+func TestCheckKeys(t *testing.T) {
+	bindings := map[string]keyList{
+		"quit":   {"x"},
+		"reload": {"q"},     // free once quit moves to x
+		"down":   {"space"}, // queues scripts
+		"up":     {"ctrl+"},
+		"jump":   {"J"},
+		"filter": {"s", "ctrl+f"},
+		"run":    {"x"}, // taken by quit
+	}
+	checked, warnings := checkKeys(bindings)
+
+	want := map[string]keyList{
+		"quit":   {"x"},
+		"reload": {"q"},
+		"filter": {"s", "ctrl+f"},
+	}
+	if !reflect.DeepEqual(checked, want) {
+		t.Errorf("checkKeys() = %q, want %q", checked, want)
+	}
+	if len(warnings) != 4 {
+		t.Errorf("checkKeys() warnings = %q, want 4 for down, up, jump and run", warnings)
+	}
+
+	keys := keysFor(checked)
+	for key, action := range map[string]string{"x": "quit", "q": "reload", "s": "filter", "/": "", "j": "down", "enter": "run"} {
+		if got := keys.action(key); got != action {
+			t.Errorf("action(%q) = %q, want %q", key, got, action)
+		}
+	}
+}
+
+func TestAssignGroups(t *testing.T) {
+	names := []string{"build", "test", "test:unit", "test:e2e", "lint:fix", "web/dev"}
+	items := make([]list.Item, len(names))
+	for index, name := range names {
+		items[index] = item{name: name, source: "npm"}
+	}
+	assignGroups(items, map[string]workspaceScript{"web/dev": {Workspace: "web", Script: "dev"}})
+
+	want := []string{"general", "test", "test", "test", "lint", "web"}
+	for index, listItem := range items {
+		if got := listItem.(item).group; got != want[index] {
+			t.Errorf("group of %s = %q, want %q", names[index], got, want[index])
+		}
+	}
+
+	// Nothing is grouped when every script would share a group
+	items = []list.Item{item{name: "build", source: "npm"}, item{name: "test", source: "npm"}}
+	assignGroups(items, nil)
+	for _, listItem := range items {
+		if group := listItem.(item).group; group != "" {
+			t.Errorf("group of %s = %q, want none", listItem.(item).name, group)
+		}
+	}
+}
+
+func TestShellSourceResolveCommand(t *testing.T) {
+	t.Setenv("SHELL", "/bin/sh")
+	source := &shellSource{ScriptSource: &fakeSource{}}
+	path, args, err := source.ResolveCommand("it's", []string{"a b"})
+	if err != nil {
+		t.Fatalf("ResolveCommand() error = %v", err)
+	}
+	if path != "/bin/sh" {
+		t.Errorf("ResolveCommand() path = %q, want /bin/sh", path)
+	}
+	want := []string{"/bin/sh", "-lc", `echo 'it'\''s' 'a b'`}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("ResolveCommand() args = %q, want %q", args, want)
+	}
+
+	// A tool only the login shell's PATH has is left for it to find
+	t.Setenv("PATH", t.TempDir())
+	source = &shellSource{ScriptSource: &MakefileScriptSource{}}
+	if _, _, err := source.ScriptSource.ResolveCommand("build", nil); err == nil {
+		t.Fatal("make was found on an empty PATH")
+	}
+	_, args, err = source.ResolveCommand("build", []string{"V=1"})
+	if err != nil {
+		t.Fatalf("ResolveCommand() without make on PATH error = %v", err)
+	}
+	if want := []string{"/bin/sh", "-lc", "make build V=1"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("ResolveCommand() args = %q, want %q", args, want)
+	}
+}
+
+func TestNodeVersionMatches(t *testing.T) {
+	tests := []struct {
+		required string
+		active   string
+		want     bool
+	}{
+		{"18", "v18.17.1", true},
+		{"v18.17", "v18.17.1", true},
+		{"18.17.1", "v18.17.1", true},
+		{"18.x", "v18.2.0", true},
+		{"lts/*", "v20.0.0", true},
+		{"18", "v20.5.0", false},
+		{"18.16", "v18.17.1", false},
+		{"1", "v18.0.0", false},
+	}
+	for _, tt := range tests {
+		if got := nodeVersionMatches(tt.required, tt.active); got != tt.want {
+			t.Errorf("nodeVersionMatches(%q, %q) = %v, want %v", tt.required, tt.active, got, tt.want)
+		}
+	}
+}
+
+func TestNPMFallback(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "npm"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	source := &NPMScriptSource{Manager: "pnpm"}
+	_, _, err := source.ResolveCommand("build", nil)
+	if err == nil || !strings.Contains(err.Error(), "pnpm not found") || !strings.Contains(err.Error(), "npm run build") {
+		t.Errorf("ResolveCommand() error = %v, want one naming pnpm and npm run build", err)
+	}
+
+	source.Fallback = true
+	path, args, err := source.ResolveCommand("build", []string{"--watch"})
+	if err != nil {
+		t.Fatalf("ResolveCommand() error = %v", err)
+	}
+	want := []string{"npm", "run", "build", "--", "--watch"}
+	if path != filepath.Join(dir, "npm") || !reflect.DeepEqual(args, want) {
+		t.Errorf("ResolveCommand() = %q, %q, want %q, %q", path, args, filepath.Join(dir, "npm"), want)
+	}
+}
+
+func TestDirScriptSource(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]struct {
+		content string
+		mode    os.FileMode
+	}{
+		"setup":     {"#!/bin/sh\n# Set up the machine\n# description: Link the dotfiles\nln -s a b\n", 0o755},
+		"update.sh": {"#!/bin/sh\necho update\n# description: too late\n", 0o755},
+		"README":    {"not a script\n", 0o644},
+		".hidden":   {"#!/bin/sh\n", 0o755},
+	}
+	for name, file := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(file.content), file.mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	source := &DirScriptSource{Dir: dir}
+	items, err := source.GetScripts()
+	if err != nil {
+		t.Fatalf("GetScripts() error = %v", err)
+	}
+	var got []string
+	for _, listItem := range items {
+		i := listItem.(item)
+		got = append(got, i.name+": "+i.description)
+	}
+	want := []string{"setup: Link the dotfiles", "update.sh: script in " + dir + "/"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetScripts() = %q, want %q", got, want)
+	}
+
+	path, args, err := source.ResolveCommand("setup", []string{"--force"})
+	if err != nil {
+		t.Fatalf("ResolveCommand() error = %v", err)
+	}
+	if path != filepath.Join(dir, "setup") || !reflect.DeepEqual(args[1:], []string{"--force"}) {
+		t.Errorf("ResolveCommand() = %q, %q", path, args)
+	}
+}
+
+func TestScriptDirLastResort(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"scripts": {"build": "tsc"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "scripts"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "scripts", "release"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	names := func(opts sourceOptions) []string {
+		_, items, err := findScriptSources(opts)
+		if err != nil {
+			t.Fatalf("findScriptSources() error = %v", err)
+		}
+		var got []string
+		for _, listItem := range items {
+			got = append(got, listItem.(item).name)
+		}
+		return got
+	}
+
+	// The helper scripts of an npm project stay out of its list
+	if got, want := names(sourceOptions{}), []string{"build"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("without scripts_dir = %q, want %q", got, want)
+	}
+
+	// Unless the config asks for them
+	if got, want := names(sourceOptions{ScriptsDir: "scripts"}), []string{"build", "release"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("with scripts_dir = %q, want %q", got, want)
+	}
+
+	// Which may name another directory
+	if err := os.Mkdir(filepath.Join(dir, "tools"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tools", "deploy"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := names(sourceOptions{ScriptsDir: "tools"}), []string{"build", "deploy"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("with scripts_dir = tools = %q, want %q", got, want)
+	}
+
+	// Or the other sources have no scripts
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"scripts": {}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := names(sourceOptions{}), []string{"release"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("with an empty package.json = %q, want %q", got, want)
+	}
+
+	// Or they're all there is
+	if err := os.Remove(filepath.Join(dir, "package.json")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := names(sourceOptions{}), []string{"release"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("scripts only = %q, want %q", got, want)
+	}
+}
+
+func TestParseRxFile(t *testing.T) {
+	tests := []struct {
+		path string
+		data string
+	}{
+		{
+			path: ".rx.yaml",
+			data: "default: dev\ntasks:\n  dev: npm run dev\n  tunnel:\n    run: ssh -N db\n    description: Forward the database\n",
+		},
+		{
+			path: ".rx.toml",
+			data: "default = \"dev\"\n\n[tasks]\ndev = \"npm run dev\"\n\n[tasks.tunnel]\nrun = \"ssh -N db\"\ndescription = \"Forward the database\"\n",
+		},
+	}
+	want := rxFile{
+		Default: "dev",
+		Tasks: []rxTask{
+			{name: "dev", run: "npm run dev"},
+			{name: "tunnel", run: "ssh -N db", description: "Forward the database"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := parseRxFile(tt.path, []byte(tt.data))
+			if err != nil {
+				t.Fatalf("parseRxFile() error = %v", err)
+			}
+			// Only YAML knows the lines of tasks
+			for index := range got.Tasks {
+				got.Tasks[index].line = 0
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("parseRxFile() = %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	if _, err := parseRxFile(".rx.yaml", []byte("tasks:\n  empty:\n    description: nothing\n")); err == nil {
+		t.Error("parseRxFile() error = nil for a task without a command")
+	}
+}
+
+func TestFirstMatch(t *testing.T) {
+	items := []list.Item{
+		item{name: "build", source: "npm"},
+		item{name: "build:prod", source: "npm"},
+		item{name: "test:e2e", source: "npm"},
+		item{name: "test:e2e", source: "make"},
+		item{name: "lint", source: "npm"},
+	}
+
+	tests := []struct {
+		query      string
+		want       string
+		candidates []string
+	}{
+		{query: "build", want: "build"},
+		{query: "lnt", want: "lint"},
+		{query: "e2e", want: "test:e2e"},
+		{query: "bld", candidates: []string{"build", "build:prod"}},
+		{query: "deploy", candidates: []string{}},
+	}
+	for _, tt := range tests {
+		i, candidates, ok := firstMatch(tt.query, items)
+		if ok != (tt.want != "") || i.name != tt.want || !reflect.DeepEqual(candidates, tt.candidates) {
+			t.Errorf("firstMatch(%q) = %q, %q, %v, want %q, %q", tt.query, i.name, candidates, ok, tt.want, tt.candidates)
+		}
+	}
+}
+
+func TestSortByFrequency(t *testing.T) {
+	items := []list.Item{
+		item{name: "build", source: "npm"},
+		item{name: "lint", source: "npm"},
+		item{name: "test", source: "npm"},
+		item{name: "test", source: "make"},
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	runs := []struct {
+		dir, name, source string
+		count             int
+	}{
+		{"/app", "build", "npm", 1},
+		{"/app", "lint", "npm", 2},
+		{"/app", "test", "make", 3},
+		{"/other", "build", "npm", 4},
+	}
+	for _, run := range runs {
+		for n := 0; n < run.count; n++ {
+			if err := recordStats(run.dir, run.name, run.source); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	stats, err := loadStats("/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, listItem := range sortByFrequency(items, stats) {
+		i := listItem.(item)
+		got = append(got, i.source+":"+i.name)
+	}
+	want := []string{"make:test", "npm:lint", "npm:build", "npm:test"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sortByFrequency() = %q, want %q", got, want)
+	}
+}
+
+func TestSortItemsWithoutHistory(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	items := []list.Item{
+		item{name: "test", source: "npm"},
+		item{name: "build", source: "npm"},
+		item{name: "lint", source: "make"},
+	}
+	names := func() []string {
+		var got []string
+		for _, listItem := range sortItems(items, "", "/app") {
+			got = append(got, listItem.(item).name)
+		}
+		return got
+	}
+	want := []string{"build", "lint", "test"}
+
+	// An empty history sorts by name
+	if got := names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("sortItems() with no history = %q, want %q", got, want)
+	}
+
+	// So does a history of other directories only
+	if err := recordHistory("/other", "test", "npm"); err != nil {
+		t.Fatal(err)
+	}
+	if got := names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("sortItems() with history elsewhere = %q, want %q", got, want)
+	}
+}
+
+func TestClearHistory(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if err := clearHistory(); err != nil {
+		t.Fatalf("clearHistory() without a history error = %v", err)
+	}
+	if err := recordHistory("/app", "test", "npm"); err != nil {
+		t.Fatal(err)
+	}
+	if err := clearHistory(); err != nil {
+		t.Fatalf("clearHistory() error = %v", err)
+	}
+	history, err := loadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 0 {
+		t.Errorf("loadHistory() after clearHistory() = %v, want none", history)
+	}
+}
+
+func TestMakeCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "Makefile")
+	if err := os.WriteFile(path, []byte("build:\n\tgo build\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loadMakeCache(path); ok {
+		t.Fatal("loadMakeCache() hit before anything was saved")
+	}
+
+	targets := []string{"build"}
+	recipes := map[string]string{"build": "go build"}
+	if err := saveMakeCache(path, targets, recipes); err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := loadMakeCache(path)
+	if !ok || !reflect.DeepEqual(entry.Targets, targets) || !reflect.DeepEqual(entry.Recipes, recipes) {
+		t.Errorf("loadMakeCache() = %v, %v, want the saved targets", entry, ok)
+	}
+
+	// Editing the Makefile invalidates the cache
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loadMakeCache(path); ok {
+		t.Error("loadMakeCache() hit after the Makefile changed")
+	}
+
+	// So does a cache parsed by another version of rx
+	if err := saveMakeCache(path, targets, recipes); err != nil {
+		t.Fatal(err)
+	}
+	if entry, ok = loadMakeCache(path); !ok {
+		t.Fatal("loadMakeCache() missed the cache saved again")
+	}
+	cachePath, err := makeCachePath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Version = makeCacheVersion - 1
+	if err := saveJSON(cachePath, entry); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loadMakeCache(path); ok {
+		t.Error("loadMakeCache() hit a cache of another version")
+	}
+}
+
+func TestSaveFilter(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if filter, err := loadFilter("/app"); err != nil || filter != "" {
+		t.Fatalf("loadFilter() without a file = %q, %v", filter, err)
+	}
+
+	for dir, filter := range map[string]string{"/app": "test", "/other": "build"} {
+		if err := saveFilter(dir, filter); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if filter, err := loadFilter("/app"); err != nil || filter != "test" {
+		t.Errorf("loadFilter(/app) = %q, %v, want test", filter, err)
+	}
+
+	// Clearing the filter forgets it, keeping other directories
+	if err := saveFilter("/app", ""); err != nil {
+		t.Fatal(err)
+	}
+	all, err := loadAllFilters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"/other": "build"}; !reflect.DeepEqual(all, want) {
+		t.Errorf("loadAllFilters() = %v, want %v", all, want)
+	}
+}
+
+func TestUpdateJSONConcurrent(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	// Every update is kept, as each reads the file the one before it saved
+	// even when another waits between its read and its write
+	const runs = 50
+	path, _ := statePath("counter.json")
+	var wg sync.WaitGroup
+	for n := 0; n < runs; n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			err := updateJSON(path, func() error {
+				var counter int
+				if err := loadJSON(path, &counter); err != nil {
+					return err
+				}
+				time.Sleep(time.Millisecond)
+				return saveJSON(path, counter+1)
+			})
+			if err != nil {
+				t.Error(err)
+			}
+			if err := recordStats("/app", "build", "npm"); err != nil {
+				t.Error(err)
+			}
+			dir := "/app" + string(rune('a'+n%5))
+			if err := saveFavorites(dir, map[string]bool{favoriteKey("npm", "build"): true}); err != nil {
+				t.Error(err)
+			}
+		}(n)
+	}
+	wg.Wait()
+
+	var counter int
+	if err := loadJSON(path, &counter); err != nil {
+		t.Fatal(err)
+	}
+	if counter != runs {
+		t.Errorf("counter = %d, want %d", counter, runs)
+	}
+	stats, err := loadStats("/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 1 || stats[0].Count != runs {
+		t.Errorf("loadStats() = %+v, want build counted %d times", stats, runs)
+	}
+	favorites, err := loadAllFavorites()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(favorites) != 5 {
+		t.Errorf("loadAllFavorites() has %d directories, want 5", len(favorites))
+	}
+
+	// No temporary files are left behind
+	dir, _ := configDir()
+	temps, _ := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	if len(temps) > 0 {
+		t.Errorf("temporary files left behind: %q", temps)
+	}
+}
+
+func TestScriptFlags(t *testing.T) {
+	config := map[string][]string{"test": {"--watch", "--coverage"}}
+	test := item{name: "test", source: "npm"}
+	build := item{name: "build", source: "npm"}
+
+	if got := scriptFlags(config, []item{test}); !reflect.DeepEqual(got, []string{"--watch", "--coverage"}) {
+		t.Errorf("scriptFlags(test) = %q", got)
+	}
+	if got := scriptFlags(config, []item{build}); got != nil {
+		t.Errorf("scriptFlags(build) = %q, want none", got)
+	}
+	// The chosen flags would be passed to every queued script
+	if got := scriptFlags(config, []item{test, build}); got != nil {
+		t.Errorf("scriptFlags(test, build) = %q, want none", got)
+	}
+}
+
+func TestRecentDirs(t *testing.T) {
+	app, api, web := t.TempDir(), t.TempDir(), t.TempDir()
+	history := []historyEntry{
+		{Dir: app, Name: "test"},
+		{Dir: api, Name: "build"},
+		{Dir: app, Name: "build"},
+		{Dir: filepath.Join(app, "removed"), Name: "lint"},
+		{Dir: web, Name: "dev"},
+	}
+
+	if got, want := recentDirs(history, 10), []string{app, api, web}; !reflect.DeepEqual(got, want) {
+		t.Errorf("recentDirs() = %q, want %q", got, want)
+	}
+	if got, want := recentDirs(history, 2), []string{app, api}; !reflect.DeepEqual(got, want) {
+		t.Errorf("recentDirs(limit 2) = %q, want %q", got, want)
+	}
+}
+
+func TestProfilerPrint(t *testing.T) {
+	// Without --profile nothing is recorded or printed
+	var off *profiler
+	off.track("config", time.Now())
+	var buf bytes.Buffer
+	off.print(&buf)
+	if buf.Len() != 0 {
+		t.Errorf("nil profiler printed %q", buf.String())
+	}
+
+	p := newProfiler()
+	p.track("detect sources", time.Now().Add(-2*time.Millisecond))
+	p.track("make scripts from Makefile", time.Now().Add(-812*time.Millisecond))
+	p.print(&buf)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("print() = %q, want a title, 2 phases and the total", buf.String())
+	}
+	for n, name := range []string{"detect sources", "make scripts from Makefile", "total"} {
+		if !strings.HasPrefix(strings.TrimSpace(lines[n+1]), name) || !strings.HasSuffix(lines[n+1], "ms") {
+			t.Errorf("line %d = %q, want %s and its time", n+1, lines[n+1], name)
+		}
+	}
+}
+
+// slowSource is a fakeSource that takes delay to list its scripts
+type slowSource struct {
+	fakeSource
+	delay time.Duration
+	err   error
+}
+
+func (s *slowSource) GetScripts() ([]list.Item, error) {
+	time.Sleep(s.delay)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.items, nil
+}
+
+func TestListSources(t *testing.T) {
+	detected := func(tag string, source ScriptSource) detectedSource {
+		return detectedSource{detector: sourceDetector{tag: tag, file: tag + "file"}, source: source}
+	}
+	slow := func(name string, delay time.Duration) *slowSource {
+		return &slowSource{fakeSource: fakeSource{items: []list.Item{item{name: name}}}, delay: delay}
+	}
+
+	// Three sources taking 200ms each are listed in about 200ms, in order,
+	// and a failing source doesn't stop the others
+	sources := []detectedSource{
+		detected("make", slow("build", 200*time.Millisecond)),
+		detected("npm", &slowSource{delay: 100 * time.Millisecond, err: errors.New("broken package.json")}),
+		detected("gradle", slow("assemble", 200*time.Millisecond)),
+		detected("just", slow("lint", 0)),
+	}
+	start := time.Now()
+	results := listSources(sources, 0, nil)
+	if took := time.Since(start); took > 500*time.Millisecond {
+		t.Errorf("listSources() took %v, want the sources listed at the same time", took)
+	}
+	var got []string
+	for _, result := range results {
+		if result.err != nil {
+			got = append(got, "error: "+result.err.Error())
+			continue
+		}
+		got = append(got, result.items[0].(item).name)
+	}
+	want := []string{"build", "error: broken package.json", "assemble", "lint"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("listSources() = %q, want %q", got, want)
+	}
+
+	// A stuck source is given up on after the wait, keeping the others
+	sources = []detectedSource{
+		detected("make", slow("build", 5*time.Second)),
+		detected("npm", slow("test", 0)),
+	}
+	start = time.Now()
+	results = listSources(sources, 100*time.Millisecond, nil)
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("listSources() took %v, want it to stop waiting after 100ms", took)
+	}
+	if !errors.Is(results[0].err, errListTimeout) {
+		t.Errorf("stuck source error = %v, want errListTimeout", results[0].err)
+	}
+	if results[1].err != nil || len(results[1].items) != 1 {
+		t.Errorf("other source = %+v, want its script", results[1])
+	}
+}
+
+func TestDelegateMaxDescription(t *testing.T) {
+	command := "\x1b[2mnode scripts/build.js --config webpack.config.js --mode production && cp -r public dist\x1b[0m"
+	i := item{name: "build", description: command, source: "npm"}
+
+	tests := []struct {
+		name string
+		max  int
+		want int // columns of the description line, with its padding
+	}{
+		{name: "list width", max: 0, want: 40},
+		{name: "max description", max: 20, want: 2 + 20},
+		{name: "wider than the list", max: 100, want: 40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newItemDelegate(themes["dark"])
+			d.MaxDescription = tt.max
+			l := list.New([]list.Item{i}, d, 40, 10)
+			var b strings.Builder
+			d.Render(&b, l, 1, i)
+			lines := strings.Split(b.String(), "\n")
+			desc := strings.TrimRight(lines[1], " ")
+			if got := lipgloss.Width(desc); got != tt.want {
+				t.Errorf("description %q is %d columns, want %d", desc, got, tt.want)
+			}
+			if !strings.Contains(desc, ellipsis) {
+				t.Errorf("description %q isn't cut with an ellipsis", desc)
+			}
+		})
+	}
+}
+
+func TestRunSummary(t *testing.T) {
+	m := model{
+		sources: map[string]ScriptSource{"fake": &fakeSource{}},
+		dir:     "/project",
+		dotenv:  []string{"PORT=3000", "DEBUG=0"},
+		env:     []string{"DEBUG=1"},
+	}
+	summary := m.runSummary([]item{{name: "build", source: "fake"}}, []string{"--watch"})
+
+	var lines []string
+	for _, line := range strings.Split(summary, "\n") {
+		lines = append(lines, strings.Join(strings.Fields(line), " "))
+	}
+	want := []string{"About to run", "", "Command echo build --watch", "Source fake", "Directory /project", "Environment PORT=3000", "DEBUG=1"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("runSummary() = %q, want %q", lines, want)
+	}
+
+	m.dotenv, m.env = nil, nil
+	if summary := m.runSummary([]item{{name: "build", source: "fake"}}, nil); !strings.Contains(summary, "unchanged") {
+		t.Errorf("runSummary() = %q, want the environment unchanged", summary)
+	}
+}
+
+func TestExpandInstallPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path, want string
+	}{
+		{"~", home},
+		{"~/bin", filepath.Join(home, "bin")},
+		{"bin", filepath.Join(wd, "bin")},
+		{"/usr/local/bin/", "/usr/local/bin"},
+		{"~user/bin", filepath.Join(wd, "~user/bin")},
+	}
+	for _, tt := range tests {
+		got, err := expandInstallPath(tt.path)
+		if err != nil {
+			t.Fatalf("expandInstallPath(%q) error = %v", tt.path, err)
+		}
+		if got != tt.want {
+			t.Errorf("expandInstallPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCheckWritable(t *testing.T) {
+	dir := t.TempDir()
+	if err := checkWritable(dir); err != nil {
+		t.Errorf("checkWritable() error = %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("checkWritable() left %d files behind", len(entries))
+	}
+
+	if err := checkWritable(filepath.Join(dir, "missing")); err == nil {
+		t.Error("checkWritable() of a missing directory succeeded")
+	}
+
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("permissions aren't enforced")
+	}
+	readOnly := filepath.Join(dir, "read-only")
+	if err := os.Mkdir(readOnly, 0o555); err != nil {
+		t.Fatal(err)
+	}
+	err := checkWritable(readOnly)
+	if err == nil || !strings.Contains(err.Error(), "is not writable") {
+		t.Errorf("checkWritable() of a read-only directory error = %v", err)
+	}
+}
+
+func TestVerifyInstall(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	script := "#!/bin/sh\necho rx 1.0.0\n"
+	if err := os.WriteFile(src, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		mode    os.FileMode
+		want    string
+	}{
+		{"copy", script, 0o755, ""},
+		{"truncated", script[:10], 0o755, "differs from"},
+		{"not executable", script, 0o644, "isn't executable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "rx")
+			if err := os.WriteFile(dest, []byte(tt.content), tt.mode); err != nil {
+				t.Fatal(err)
+			}
+			err := verifyInstall(src, dest)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("verifyInstall() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("verifyInstall() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	// An identical copy that runs but isn't rx
+	hello := filepath.Join(dir, "hello")
+	if err := os.WriteFile(hello, []byte("#!/bin/sh\necho hello\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	err := verifyInstall(hello, hello)
+	if err == nil || !strings.Contains(err.Error(), `--version printed "hello"`) {
+		t.Errorf("verifyInstall() of another program error = %v", err)
+	}
+}
+
+func TestInstallRxKeepsInstallOnFailure(t *testing.T) {
+	// The test binary doesn't print rx's --version, so it fails to verify
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "rx")
+	if err := os.WriteFile(dest, []byte("#!/bin/sh\necho rx 1.0.0\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := installRx(dir); err == nil {
+		t.Fatal("installRx() of the test binary succeeded")
+	}
+	data, err := os.ReadFile(dest)
+	if err != nil || string(data) != "#!/bin/sh\necho rx 1.0.0\n" {
+		t.Errorf("installed rx = %q, %v, want it untouched", data, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("installRx() left %d files in the install directory, want 1", len(entries))
+	}
+}
+
+func TestSameFile(t *testing.T) {
+	dir := t.TempDir()
+	rx := filepath.Join(dir, "rx")
+	other := filepath.Join(dir, "other")
+	link := filepath.Join(dir, "link")
+	for _, path := range []string{rx, other} {
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(rx, link); err != nil {
+		t.Skip("symlinks unsupported:", err)
+	}
+
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{rx, rx, true},
+		{link, rx, true},
+		{rx, link, true},
+		{rx, other, false},
+		{rx, filepath.Join(dir, "missing"), false},
+	}
+	for _, tt := range tests {
+		if got := sameFile(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameFile(%s, %s) = %v, want %v", filepath.Base(tt.a), filepath.Base(tt.b), got, tt.want)
+		}
+	}
+}
+
+func TestFilterEnterOnlyFocusesList(t *testing.T) {
+	items := []list.Item{
+		item{name: "build", source: "fake"},
+		item{name: "test", source: "fake"},
+	}
+	m := model{
+		list:          list.New(nil, newItemDelegate(themes["dark"]), 80, 20),
+		allItems:      items,
+		filterFocused: true,
+		filterInput:   "build",
+		keys:          keysFor(nil),
+		sources:       map[string]ScriptSource{"fake": &fakeSource{}},
+	}
+	m.applyFilter()
+	if !m.singleMatch() {
+		t.Fatal("the filter should match only build")
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(model)
+	if m.filterFocused {
+		t.Error("enter left the filter focused")
+	}
+	if len(m.selected) > 0 || cmd != nil {
+		t.Errorf("enter ran %v, want it to only focus the list", m.selected)
+	}
+	if i, ok := m.list.SelectedItem().(item); !ok || i.name != "build" {
+		t.Errorf("selected %v, want build still highlighted", m.list.SelectedItem())
+	}
+}
+
+// locatingSource is a fakeSource that counts how often its scripts are
+// located
+type locatingSource struct {
+	fakeSource
+	located int
+}
+
+func (l *locatingSource) Locate(name string) (string, int, error) {
+	l.located++
+	return "fake.json", len(name), nil
+}
+
+func TestPreviewLocationCached(t *testing.T) {
+	source := &locatingSource{}
+	items := []list.Item{
+		item{name: "build", source: "fake"},
+		item{name: "test", source: "fake"},
+	}
+	m := model{
+		list:      list.New(items, newItemDelegate(themes["dark"]), 80, 20),
+		allItems:  items,
+		keys:      keysFor(nil),
+		sources:   map[string]ScriptSource{"fake": source},
+		paneWidth: 60,
+	}
+	update := func(msg tea.Msg) {
+		updated, _ := m.Update(msg)
+		m = updated.(model)
+	}
+
+	// Redrawing and ticking don't locate the script again
+	update(spinner.TickMsg{})
+	for n := 0; n < 3; n++ {
+		if pane := m.previewPane(10); !strings.Contains(pane, "fake.json:5") {
+			t.Fatalf("previewPane() = %q, want build's location", pane)
+		}
+		update(spinner.TickMsg{})
+	}
+	if source.located != 1 {
+		t.Errorf("located build %d times, want once", source.located)
+	}
+
+	// Moving the highlight does
+	update(tea.KeyMsg{Type: tea.KeyDown})
+	if pane := m.previewPane(10); !strings.Contains(pane, "fake.json:4") {
+		t.Errorf("previewPane() = %q, want test's location", pane)
+	}
+	if source.located != 2 {
+		t.Errorf("located %d times after moving, want twice", source.located)
+	}
+}
+
+func TestNoSourceViewShowsCause(t *testing.T) {
+	wd, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	cause := errors.New("make: no targets")
+	m := model{dir: "/"}
+	m.openNoSource(fmt.Errorf("%w: %w", errNoSource, errors.Join(cause)))
+	if view := m.noSourceView(); !strings.Contains(view, cause.Error()) {
+		t.Errorf("noSourceView() = %q, want it to show %q", view, cause)
+	}
+
+	m.openNoSource(errNoSource)
+	if got := noSourceCause(m.noSourceErr); got != nil {
+		t.Errorf("noSourceCause(errNoSource) = %v, want nil", got)
+	}
+}
+
+func TestChooseNoSourceParentReadsDotenv(t *testing.T) {
+	parent, _ := filepath.EvalSymlinks(t.TempDir())
+	child := filepath.Join(parent, "child")
+	if err := os.Mkdir(child, 0755); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(child); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	m := model{
+		dir:    child,
+		dotenv: []string{"FROM=child"},
+		readDotenv: func() ([]string, error) {
+			dir, _ := os.Getwd()
+			return []string{"FROM=" + filepath.Base(dir)}, nil
+		},
+		load: func(string) tea.Cmd { return nil },
+	}
+	m, _ = m.chooseNoSource(noSourceParent)
+
+	if dir, _ := os.Getwd(); dir != parent || m.dir != parent {
+		t.Errorf("working directory %s, model %s, want %s", dir, m.dir, parent)
+	}
+	if want := []string{"FROM=" + filepath.Base(parent)}; !reflect.DeepEqual(m.dotenv, want) {
+		t.Errorf("dotenv = %q, want the parent's %q", m.dotenv, want)
+	}
+}
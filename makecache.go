@@ -0,0 +1,89 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// makeCacheVersion is the version of the targets and recipes cached. Bump
+// it when the parsing of the make database changes, so caches parsed
+// the old way are ignored.
+const makeCacheVersion = 1
+
+// makeCacheEntry is the parsed make database of one Makefile, valid while
+// the Makefile's modification time and makeCacheVersion are unchanged
+type makeCacheEntry struct {
+	Version int               `json:"version"`
+	Path    string            `json:"path"`
+	ModTime time.Time         `json:"mod_time"`
+	Targets []string          `json:"targets"`
+	Recipes map[string]string `json:"recipes"`
+}
+
+// cacheDir returns the directory rx keeps caches in, ~/.cache/rx unless
+// XDG_CACHE_HOME says otherwise
+func cacheDir() (string, error) {
+	if xdg := os.Getenv("XDG_CACHE_HOME"); xdg != "" {
+		return filepath.Join(xdg, "rx"), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".cache", "rx"), nil
+}
+
+// makeCachePath returns the cache file for the Makefile at path
+func makeCachePath(path string) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(path))
+	return filepath.Join(dir, "make", hex.EncodeToString(sum[:])+".json"), nil
+}
+
+// loadMakeCache returns the cached targets and recipes of the Makefile at
+// path, or false when there are none, the Makefile changed since or they
+// were parsed by another version of rx
+func loadMakeCache(path string) (makeCacheEntry, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return makeCacheEntry{}, false
+	}
+	cachePath, err := makeCachePath(path)
+	if err != nil {
+		return makeCacheEntry{}, false
+	}
+
+	var entry makeCacheEntry
+	if err := loadJSON(cachePath, &entry); err != nil || entry.Path != path || entry.Version != makeCacheVersion {
+		return makeCacheEntry{}, false
+	}
+	if !entry.ModTime.Equal(info.ModTime()) {
+		return makeCacheEntry{}, false
+	}
+	return entry, true
+}
+
+// saveMakeCache stores the targets and recipes of the Makefile at path
+func saveMakeCache(path string, targets []string, recipes map[string]string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	cachePath, err := makeCachePath(path)
+	if err != nil {
+		return err
+	}
+	return saveJSON(cachePath, makeCacheEntry{
+		Version: makeCacheVersion,
+		Path:    path,
+		ModTime: info.ModTime(),
+		Targets: targets,
+		Recipes: recipes,
+	})
+}
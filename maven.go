@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// mavenPhases are the common lifecycle phases offered for every pom
+var mavenPhases = []struct {
+	name        string
+	description string
+}{
+	{"clean", "Remove files generated by the previous build"},
+	{"compile", "Compile the source code"},
+	{"test", "Run the unit tests"},
+	{"package", "Package the compiled code, e.g. as a JAR"},
+	{"verify", "Run integration tests and checks"},
+	{"install", "Install the package into the local repository"},
+}
+
+// mavenProfilePrefix marks items that build with a profile of the pom
+const mavenProfilePrefix = "profile:"
+
+// MavenScriptSource handles lifecycle phases and profiles of a Maven project
+type MavenScriptSource struct {
+	ArtifactID string
+	Version    string
+}
+
+func (m *MavenScriptSource) Name() string {
+	if m.ArtifactID == "" {
+		return "pom.xml"
+	}
+	return fmt.Sprintf("%s@%s", m.ArtifactID, m.Version)
+}
+
+func (m *MavenScriptSource) GetScripts() ([]list.Item, error) {
+	// Read pom.xml for the artifact and its profiles
+	data, err := os.ReadFile("pom.xml")
+	if err != nil {
+		return nil, fmt.Errorf("error reading pom.xml: %w", err)
+	}
+
+	var pom struct {
+		ArtifactID string `xml:"artifactId"`
+		Version    string `xml:"version"`
+		Profiles   []struct {
+			ID string `xml:"id"`
+		} `xml:"profiles>profile"`
+	}
+	if err := xml.Unmarshal(data, &pom); err != nil {
+		return nil, fmt.Errorf("error parsing pom.xml: %w", err)
+	}
+	m.ArtifactID = pom.ArtifactID
+	m.Version = pom.Version
+
+	// Create items for the lifecycle phases
+	items := []list.Item{}
+	for _, phase := range mavenPhases {
+		items = append(items, item{name: phase.name, description: phase.description, command: "mvn " + phase.name, source: "maven"})
+	}
+
+	// Add a package build for each profile
+	for _, profile := range pom.Profiles {
+		id := strings.TrimSpace(profile.ID)
+		if id == "" {
+			continue
+		}
+		args := mavenArgs(mavenProfilePrefix + id)
+		items = append(items, item{
+			name:        mavenProfilePrefix + id,
+			description: "package with the " + id + " profile",
+			command:     "mvn " + strings.Join(args, " "),
+			source:      "maven",
+		})
+	}
+
+	return items, nil
+}
+
+// CommandArgs returns the mvn command line running name, without
+// looking for mvn
+func (m *MavenScriptSource) CommandArgs(name string, extraArgs []string) ([]string, error) {
+	// Prepare arguments for mvn, extra args are appended directly
+	args := append([]string{"mvn"}, mavenArgs(name)...)
+	args = append(args, extraArgs...)
+
+	return args, nil
+}
+
+func (m *MavenScriptSource) ResolveCommand(name string, extraArgs []string) (string, []string, error) {
+	// Find the path to mvn executable
+	mvnPath, err := exec.LookPath("mvn")
+	if err != nil {
+		return "", nil, fmt.Errorf("mvn not found: %w", err)
+	}
+
+	args, err := m.CommandArgs(name, extraArgs)
+	if err != nil {
+		return "", nil, err
+	}
+	return mvnPath, args, nil
+}
+
+func (m *MavenScriptSource) RunScript(name string, extraArgs, env []string) error {
+	path, args, err := m.ResolveCommand(name, extraArgs)
+	if err != nil {
+		return err
+	}
+
+	// Replace the current process with the command
+	return runExec(path, args, env)
+}
+
+// mavenArgs returns the mvn arguments for an item, a phase or a profile
+func mavenArgs(name string) []string {
+	if id, ok := strings.CutPrefix(name, mavenProfilePrefix); ok {
+		return []string{"-P" + id, "package"}
+	}
+	return []string{name}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// doubleClickTime is the longest gap between the clicks of a double click
+const doubleClickTime = 400 * time.Millisecond
+
+// handleMouse scrolls the list with the wheel, selects a clicked script and
+// runs it when clicked twice, like enter. Clicking the filter focuses it.
+// The wheel scrolls the pager too. Prompts and the no source screen are
+// left to the keyboard.
+func (m model) handleMouse(msg tea.MouseMsg) (model, tea.Cmd) {
+	if m.viewing {
+		var cmd tea.Cmd
+		m.pager, cmd = m.pager.Update(msg)
+		return m, cmd
+	}
+	if m.loading || m.noSource || m.confirming || m.argsFocused || m.envFocused || m.flagsFocused {
+		return m, nil
+	}
+
+	switch {
+	case msg.Button == tea.MouseButtonWheelUp:
+		m.list.CursorUp()
+		m.skipHeader(false)
+		return m, nil
+	case msg.Button == tea.MouseButtonWheelDown:
+		m.list.CursorDown()
+		m.skipHeader(true)
+		return m, nil
+	case msg.Button != tea.MouseButtonLeft || msg.Action != tea.MouseActionPress:
+		return m, nil
+	}
+
+	if m.error != "" {
+		// Like a key, a click dismisses the error banner
+		m.error = ""
+		return m, nil
+	}
+
+	// Rows are counted from the top of the view, inside its margin
+	row := msg.Y - docStyle.GetMarginTop()
+	filterHeight := lipgloss.Height(m.filterView())
+	if row >= 0 && row < filterHeight {
+		m.filterFocused = true
+		return m, nil
+	}
+
+	// The preview pane beside the list isn't clickable
+	if m.paneWidth > 0 && msg.X-docStyle.GetMarginLeft() >= m.list.Width() {
+		return m, nil
+	}
+	index, ok := m.itemAt(row - filterHeight)
+	if !ok {
+		return m, nil
+	}
+	m.filterFocused = false
+
+	doubleClick := index == m.lastClickIndex && time.Since(m.lastClick) < doubleClickTime
+	m.lastClick = time.Now()
+	m.lastClickIndex = index
+	m.list.Select(index)
+	if doubleClick {
+		m.lastClick = time.Time{}
+		if items := m.runTargets(); len(items) > 0 {
+			return m.runItems(items, m.extraArgs)
+		}
+	}
+	return m, nil
+}
+
+// itemAt returns the index of the script shown at row of the list view, or
+// false when the row is a header, the space between items or outside the
+// items
+func (m model) itemAt(row int) (int, bool) {
+	title := m.list.Styles.TitleBar.Render(m.list.Styles.Title.Render(m.list.Title))
+	row -= lipgloss.Height(title)
+	if row < 0 || m.itemHeight <= 0 || row%(m.itemHeight+m.itemSpacing) >= m.itemHeight {
+		return 0, false
+	}
+
+	items := m.list.Items()
+	start, end := m.list.Paginator.GetSliceBounds(len(items))
+	index := start + row/(m.itemHeight+m.itemSpacing)
+	if index >= end {
+		return 0, false
+	}
+	if _, ok := items[index].(item); !ok {
+		return 0, false
+	}
+	return index, true
+}
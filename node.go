@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// nodeVersionFiles pin the node version of a project, for nvm, fnm and
+// the like, in the order they're read
+var nodeVersionFiles = []string{".nvmrc", ".node-version"}
+
+// requiredNodeVersion returns the node version pinned by the first of
+// nodeVersionFiles in the working directory and that file, or "" when
+// none pins one
+func requiredNodeVersion() (string, string) {
+	for _, file := range nodeVersionFiles {
+		f, err := os.Open(file)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" && !strings.HasPrefix(line, "#") {
+				f.Close()
+				return line, file
+			}
+		}
+		f.Close()
+	}
+	return "", ""
+}
+
+// nodeVersionMatches reports whether the active node version, as printed
+// by node --version, satisfies the required one: 18 matches v18.17.1, and
+// 18.17 or 18.x do too. Aliases like lts/* can't be checked and match.
+func nodeVersionMatches(required, active string) bool {
+	required = strings.TrimPrefix(strings.TrimSpace(required), "v")
+	active = strings.TrimPrefix(strings.TrimSpace(active), "v")
+	if required == "" || required[0] < '0' || required[0] > '9' {
+		return true
+	}
+
+	activeParts := strings.Split(active, ".")
+	for index, part := range strings.Split(required, ".") {
+		if part == "x" || part == "*" {
+			return true
+		}
+		if index >= len(activeParts) || activeParts[index] != part {
+			return false
+		}
+	}
+	return true
+}
+
+// activeNodeVersion runs node --version, through shell's login shell when
+// it's set, so it finds the node the scripts run with
+func activeNodeVersion(shell string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "node", "--version")
+	if shell != "" {
+		cmd = exec.CommandContext(ctx, shell, "-lc", "node --version")
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// checkNodeVersion warns before running a script when the node it would
+// run with isn't the version the project pins, since a mismatch tends to
+// fail in confusing ways
+func (n *NPMScriptSource) checkNodeVersion(shell string) {
+	if n.NodeVersion == "" {
+		return
+	}
+	active, err := activeNodeVersion(shell)
+	var warning string
+	switch {
+	case err != nil:
+		warning = fmt.Sprintf("Warning: %s wants node %s but node wasn't found", n.NodeVersionFile, n.NodeVersion)
+	case !nodeVersionMatches(n.NodeVersion, active):
+		warning = fmt.Sprintf("Warning: %s wants node %s but %s is active, run with --use-nvm to switch", n.NodeVersionFile, n.NodeVersion, active)
+	default:
+		return
+	}
+	fmt.Fprintln(os.Stderr, errorStyle.Render(warning))
+}
+
+// nvmUse is the shell setup of --use-nvm, switching to version with nvm,
+// which is loaded first in case the login shell doesn't
+func nvmUse(version string) string {
+	return `[ -s "${NVM_DIR:-$HOME/.nvm}/nvm.sh" ] && . "${NVM_DIR:-$HOME/.nvm}/nvm.sh"; nvm use --silent ` + shellQuote(version)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+)
+
+// errNoSource is returned by findScriptSources when no source has scripts
+var errNoSource = errors.New("no valid script source found")
+
+// Choices of the screen shown when no script source is found
+const (
+	noSourceNPMInit  = "npm-init"
+	noSourceMakefile = "makefile"
+	noSourceParent   = "parent"
+	noSourceQuit     = "quit"
+)
+
+// starterMakefile is written by the create a Makefile choice
+const starterMakefile = `.PHONY: build test clean
+
+build:
+	@echo "build"
+
+test:
+	@echo "test"
+
+clean:
+	@echo "clean"
+`
+
+// initFinishedMsg is sent when npm init exits
+type initFinishedMsg struct {
+	err error
+}
+
+// sourceFiles lists the files rx looks for, for the no source screen
+func sourceFiles() []string {
+	files := []string{}
+	for _, detector := range sourceDetectors {
+		if !contains(files, detector.file) {
+			files = append(files, detector.file)
+		}
+	}
+	return files
+}
+
+// printNoSourceHint tells which files rx looked for when err is
+// errNoSource, outside the TUI
+func printNoSourceHint(err error) {
+	if errors.Is(err, errNoSource) {
+		fmt.Fprintln(os.Stderr, "rx looks for "+strings.Join(sourceFiles(), ", "))
+	}
+}
+
+// noSourceCause returns the failures joined to errNoSource, the sources
+// found that listed no scripts, or nil
+func noSourceCause(err error) error {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return nil
+	}
+	var causes []error
+	for _, cause := range joined.Unwrap() {
+		if cause != errNoSource {
+			causes = append(causes, cause)
+		}
+	}
+	return errors.Join(causes...)
+}
+
+// openNoSource replaces the list with a choice of ways to get some
+// scripts, instead of quitting with err
+func (m *model) openNoSource(err error) tea.Cmd {
+	m.noSource = true
+	m.noSourceErr = err
+
+	options := []huh.Option[string]{}
+	if _, err := os.Stat("package.json"); err != nil {
+		if _, err := exec.LookPath("npm"); err == nil {
+			options = append(options, huh.NewOption("Create a package.json with npm init", noSourceNPMInit))
+		}
+	}
+	if _, err := os.Stat("Makefile"); err != nil {
+		options = append(options, huh.NewOption("Create a starter Makefile", noSourceMakefile))
+	}
+	if parent := filepath.Dir(m.dir); parent != m.dir {
+		options = append(options, huh.NewOption("Look in the parent directory, "+parent, noSourceParent))
+	}
+	options = append(options, huh.NewOption("Quit", noSourceQuit))
+
+	m.noSourceField = huh.NewSelect[string]().
+		Title("No scripts found in " + m.dir).
+		Options(options...).
+		Key("choice")
+	m.noSourceForm = huh.NewForm(huh.NewGroup(m.noSourceField)).WithShowHelp(false).WithShowErrors(false)
+	return m.noSourceForm.Init()
+}
+
+// chooseNoSource acts on the choice made on the no source screen, loading
+// the scripts again once there may be some
+func (m model) chooseNoSource(choice string) (model, tea.Cmd) {
+	switch choice {
+	case noSourceNPMInit:
+		return m, tea.ExecProcess(exec.Command("npm", "init"), func(err error) tea.Msg {
+			return initFinishedMsg{err: err}
+		})
+	case noSourceMakefile:
+		if err := os.WriteFile("Makefile", []byte(starterMakefile), 0644); err != nil {
+			return m, m.setStatus("Could not create Makefile: "+err.Error(), true)
+		}
+	case noSourceParent:
+		parent := filepath.Dir(m.dir)
+		if err := os.Chdir(parent); err != nil {
+			return m, m.setStatus("Could not change directory: "+err.Error(), true)
+		}
+		m.dir = parent
+		m.root = parent
+
+		// Run with the .env files of the parent, not of where rx started
+		var err error
+		if m.dotenv, err = m.readDotenv(); err != nil {
+			status := m.setStatus("Ignoring .env files: "+err.Error(), true)
+			reloaded, load := m.reload()
+			return reloaded, tea.Batch(load, status)
+		}
+	default:
+		m.quitting = true
+		return m, tea.Quit
+	}
+	return m.reload()
+}
+
+// reload enumerates the scripts again, showing the spinner meanwhile
+func (m model) reload() (model, tea.Cmd) {
+	m.noSource = false
+	m.loading = true
+	return m, tea.Batch(m.spinner.Tick, m.load(m.dir))
+}
+
+// noSourceView explains why there are no scripts above the choices
+func (m model) noSourceView() string {
+	var b strings.Builder
+	b.WriteString(m.noSourceForm.View())
+	b.WriteString("\n\n")
+	b.WriteString(previewStyle.Render("rx looks for " + strings.Join(sourceFiles(), ", ")))
+	if cause := noSourceCause(m.noSourceErr); cause != nil {
+		b.WriteString("\n" + errorStyle.Render(cause.Error()))
+	}
+	if m.status != "" {
+		b.WriteString("\n" + errorStyle.Render(m.status))
+	}
+	return docStyle.Render(b.String())
+}
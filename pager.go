@@ -0,0 +1,67 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// pagerHelpHeight is the lines below the pager, for its help
+const pagerHelpHeight = 2
+
+// openPager shows the whole definition of i in a scrollable viewport, to
+// read a long recipe without running it
+func (m *model) openPager(i item) {
+	m.viewing = true
+	m.viewingHelp = false
+	m.viewingItem = i
+	m.pager = viewport.New(m.width, max(m.height-pagerHelpHeight, 1))
+	m.setPagerContent()
+}
+
+// setPagerContent wraps the definition being viewed, or the help, to the
+// pager's width
+func (m *model) setPagerContent() {
+	content := definition(m.viewingItem, m.scriptFile(m.viewingItem))
+	if m.viewingHelp {
+		content = m.helpContent()
+	}
+	m.pager.SetContent(lipgloss.NewStyle().Width(m.pager.Width).Render(content))
+}
+
+// resizePager fits the pager to the window, wrapping its content again
+func (m *model) resizePager() {
+	m.pager.Width = m.width
+	m.pager.Height = max(m.height-pagerHelpHeight, 1)
+	m.setPagerContent()
+}
+
+// updatePager scrolls the pager. q, esc and v go back to the list, and ?
+// from the help.
+func (m model) updatePager(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		m.quitting = true
+		return m, tea.Quit
+	case "esc", "v", "?":
+		m.viewing = false
+		return m, nil
+	}
+	if m.keys.action(msg.String()) == "quit" {
+		m.viewing = false
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.pager, cmd = m.pager.Update(msg)
+	return m, cmd
+}
+
+// pagerView is the pager with its help below
+func (m model) pagerView() string {
+	back := m.keys.help("quit") + ", esc or v"
+	if m.viewingHelp {
+		back = "? or esc"
+	}
+	help := helpStyle.Render("↑/↓ or j/k: scroll • pgup/pgdn: page • " + back + ": back to the list")
+	return docStyle.Render(m.pager.View() + "\n\n" + help)
+}
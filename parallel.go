@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// parallelColors tell apart the output of scripts run with --parallel, set
+// from the theme
+var parallelColors []lipgloss.TerminalColor
+
+// runParallel runs scripts at the same time as child processes, prefixing
+// each line they print with the script name. It waits for all of them and
+// returns 0 when they all succeed, or the exit code of the first one in
+// items that failed. ctrl+c stops every script. env overrides variables of
+// rx's environment.
+func runParallel(sources map[string]ScriptSource, items []item, extraArgs, env []string) int {
+	width := 0
+	for _, i := range items {
+		width = max(width, lipgloss.Width(i.name))
+	}
+
+	var mu sync.Mutex
+	cmds := make([]*exec.Cmd, len(items))
+	outputs := make([][]*prefixWriter, len(items))
+	prefixes := make([]string, len(items))
+	for n, i := range items {
+		path, args, err := sources[i.source].ResolveCommand(i.name, extraArgs)
+		if err != nil {
+			fmt.Println(errorStyle.Render(fmt.Sprintf("Error: %s: %v", i.name, err)))
+			return 1
+		}
+
+		style := lipgloss.NewStyle().Foreground(parallelColors[n%len(parallelColors)])
+		prefixes[n] = style.Render(fmt.Sprintf("%-*s │ ", width, i.name))
+		stdout := &prefixWriter{w: os.Stdout, prefix: prefixes[n], mu: &mu}
+		stderr := &prefixWriter{w: os.Stderr, prefix: prefixes[n], mu: &mu}
+		outputs[n] = []*prefixWriter{stdout, stderr}
+
+		// Scripts get their own process group and no stdin, so ctrl+c
+		// only reaches rx, which stops them all
+		cmd := exec.Command(path, args[1:]...)
+		cmd.Args = args
+		cmd.Env = mergeEnv(os.Environ(), env)
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		setProcessGroup(cmd)
+		cmds[n] = cmd
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, quitSignals...)
+	defer signal.Stop(interrupt)
+
+	fmt.Println(successStyle.Render("Running in parallel: " + itemNames(items)))
+	done := make(chan int, len(cmds))
+	errs := make([]error, len(cmds))
+	running := make([]bool, len(cmds))
+	for n, cmd := range cmds {
+		if err := cmd.Start(); err != nil {
+			errs[n] = err
+			done <- n
+			continue
+		}
+		running[n] = true
+		go func(n int, cmd *exec.Cmd) {
+			errs[n] = cmd.Wait()
+			done <- n
+		}(n, cmd)
+	}
+
+	var interrupted os.Signal
+	var force <-chan time.Time
+	codes := make([]int, len(cmds))
+	for remaining := len(cmds); remaining > 0; {
+		select {
+		case n := <-done:
+			remaining--
+			running[n] = false
+			for _, output := range outputs[n] {
+				output.Flush()
+			}
+
+			var exitErr *exec.ExitError
+			status := successStyle.Render("done")
+			switch {
+			case errs[n] != nil && !errors.As(errs[n], &exitErr):
+				codes[n] = 1
+				status = errorStyle.Render(fmt.Sprintf("error: %v", errs[n]))
+			case !cmds[n].ProcessState.Success():
+				codes[n] = exitCode(cmds[n].ProcessState)
+				status = errorStyle.Render(fmt.Sprintf("exited with code %d", codes[n]))
+			}
+			mu.Lock()
+			fmt.Println(prefixes[n] + status)
+			mu.Unlock()
+
+		case sig := <-interrupt:
+			// A second ctrl+c doesn't wait for the scripts to exit
+			forced := interrupted != nil
+			interrupted = sig
+			force = time.After(watchStopTimeout)
+			for n, cmd := range cmds {
+				if running[n] {
+					stopProcess(cmd, forced)
+				}
+			}
+
+		case <-force:
+			for n, cmd := range cmds {
+				if running[n] {
+					stopProcess(cmd, true)
+				}
+			}
+		}
+	}
+
+	if interrupted != nil {
+		fmt.Println(errorStyle.Render("Interrupted"))
+		return signalExitCode(interrupted)
+	}
+	failed := []item{}
+	code := 0
+	for n, i := range items {
+		if codes[n] != 0 {
+			failed = append(failed, i)
+			if code == 0 {
+				code = codes[n]
+			}
+		}
+	}
+	if len(failed) > 0 {
+		fmt.Println(errorStyle.Render("Failed: " + itemNames(failed)))
+		return code
+	}
+	fmt.Println(successStyle.Render("Ran " + itemNames(items)))
+	return 0
+}
+
+// prefixWriter writes whole lines to w with prefix in front of each, so
+// lines of scripts running at the same time don't mix. Writers sharing mu
+// take turns.
+type prefixWriter struct {
+	w      io.Writer
+	prefix string
+	mu     *sync.Mutex
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	p.buf = append(p.buf, data...)
+	for {
+		end := bytes.IndexByte(p.buf, '\n')
+		if end < 0 {
+			break
+		}
+		p.writeLine(p.buf[:end+1])
+		p.buf = p.buf[end+1:]
+	}
+	return len(data), nil
+}
+
+// Flush writes a last line that didn't end in a newline
+func (p *prefixWriter) Flush() {
+	if len(p.buf) > 0 {
+		p.writeLine(append(p.buf, '\n'))
+		p.buf = nil
+	}
+}
+
+func (p *prefixWriter) writeLine(line []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	io.WriteString(p.w, p.prefix)
+	p.w.Write(line)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// previewMinWidth is the narrowest terminal, inside the margins, that shows
+// the preview pane beside the list
+const previewMinWidth = 100
+
+// previewGap separates the list from the preview pane
+const previewGap = 2
+
+// columnWidths splits width between the list and the preview pane. The pane
+// is 0 wide when the terminal is too narrow for it, leaving one column.
+func columnWidths(width int) (int, int) {
+	if width < previewMinWidth {
+		return width, 0
+	}
+	listWidth := width * 2 / 5
+	return listWidth, width - listWidth - previewGap
+}
+
+// scriptFile returns where the script i is defined, with its line when the
+// source can tell, or the file that marks its source
+func (m model) scriptFile(i item) string {
+	if locator, ok := m.sources[i.source].(scriptLocator); ok {
+		if path, line, err := locator.Locate(i.name); err == nil {
+			if line > 0 {
+				return fmt.Sprintf("%s:%d", path, line)
+			}
+			return path
+		}
+	}
+	// Only sources without a locator get here, and none of their files
+	// depend on the options
+	for _, detector := range sourceDetectors {
+		if detector.tag == i.source && detector.find(sourceOptions{}) {
+			return detector.file
+		}
+	}
+	return ""
+}
+
+// updateLocation finds where the highlighted script is defined, unless
+// location already has it
+func (m *model) updateLocation() {
+	i, ok := m.list.SelectedItem().(item)
+	if !ok {
+		m.location, m.locationKey = "", ""
+		return
+	}
+	key := i.source + "\x00" + i.name
+	if key == m.locationKey {
+		return
+	}
+	m.location, m.locationKey = m.scriptFile(i), key
+}
+
+// definition describes the script i, defined in file, for the preview pane
+// and the pager: its name, source file, description and whole command or
+// recipe
+func definition(i item, file string) string {
+	sections := []string{titleStyle.Copy().UnsetMargins().Render(i.Title())}
+	location := i.source
+	if file != "" {
+		location += " • " + file
+	}
+	sections = append(sections, helpStyle.Render(location))
+	if i.description != "" && i.description != i.command {
+		sections = append(sections, i.description)
+	}
+	command := i.command
+	if command == "" {
+		command = "(no recipe)"
+	}
+	lines := strings.Split(command, "\n")
+	for index := range lines {
+		lines[index] = "$ " + lines[index]
+	}
+	sections = append(sections, previewStyle.Render(strings.Join(lines, "\n")))
+	return strings.Join(sections, "\n\n")
+}
+
+// previewPane shows the definition of the highlighted script, wrapped to
+// the pane's width
+func (m model) previewPane(height int) string {
+	// The border is outside the style's width
+	style := paneStyle.Copy().Width(m.paneWidth - paneStyle.GetHorizontalBorderSize()).Height(height)
+	i, ok := m.list.SelectedItem().(item)
+	if !ok {
+		return style.Render("")
+	}
+
+	// Keep the pane within the list's height, however long the recipe. v
+	// shows the rest.
+	pane := style.Render(definition(i, m.location))
+	return lipgloss.NewStyle().MaxHeight(height).Render(pane)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// profiler times the phases of a run of rx for --profile. Its methods do
+// nothing on a nil profiler, so call sites don't check for --profile.
+type profiler struct {
+	start  time.Time
+	mu     sync.Mutex // sources are listed in the background
+	phases []profilePhase
+}
+
+// profilePhase is how long a phase took
+type profilePhase struct {
+	name string
+	took time.Duration
+}
+
+func newProfiler() *profiler {
+	return &profiler{start: time.Now()}
+}
+
+// track records the time since start as phase name
+func (p *profiler) track(name string, start time.Time) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.phases = append(p.phases, profilePhase{name: name, took: time.Since(start)})
+}
+
+// print writes the phases in the order they finished, and the time since
+// rx started
+func (p *profiler) print(w io.Writer) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "rx: profile")
+	for _, phase := range p.phases {
+		fmt.Fprintf(table, "  %s\t%s\n", phase.name, formatMillis(phase.took))
+	}
+	fmt.Fprintf(table, "  total\t%s\n", formatMillis(time.Since(p.start)))
+	table.Flush()
+}
+
+// formatMillis prints d in milliseconds, right aligned so a column of them
+// lines up
+func formatMillis(d time.Duration) string {
+	return fmt.Sprintf("%9.1fms", float64(d)/float64(time.Millisecond))
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// PyProjectScriptSource handles the scripts of a pyproject.toml, from
+// [tool.poetry.scripts] and [project.scripts], run with poetry run
+type PyProjectScriptSource struct {
+	ProjectName string
+}
+
+func (p *PyProjectScriptSource) Name() string {
+	if p.ProjectName == "" {
+		return "pyproject.toml"
+	}
+	return p.ProjectName
+}
+
+func (p *PyProjectScriptSource) GetScripts() ([]list.Item, error) {
+	var pyproject struct {
+		Project struct {
+			Name    string            `toml:"name"`
+			Scripts map[string]string `toml:"scripts"`
+		} `toml:"project"`
+		Tool struct {
+			Poetry struct {
+				Name    string                 `toml:"name"`
+				Scripts map[string]interface{} `toml:"scripts"`
+			} `toml:"poetry"`
+		} `toml:"tool"`
+	}
+	meta, err := toml.DecodeFile("pyproject.toml", &pyproject)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing pyproject.toml: %w", err)
+	}
+	p.ProjectName = pyproject.Project.Name
+	if p.ProjectName == "" {
+		p.ProjectName = pyproject.Tool.Poetry.Name
+	}
+
+	// Keep the order the scripts are declared in, poetry's first. A script
+	// in both tables is listed once.
+	items := []list.Item{}
+	seen := make(map[string]bool)
+	for _, key := range meta.Keys() {
+		var name, entryPoint string
+		switch {
+		case len(key) == 4 && key[0] == "tool" && key[1] == "poetry" && key[2] == "scripts":
+			name = key[3]
+			entryPoint = poetryEntryPoint(pyproject.Tool.Poetry.Scripts[name])
+		case len(key) == 3 && key[0] == "project" && key[1] == "scripts":
+			name = key[2]
+			entryPoint = pyproject.Project.Scripts[name]
+		default:
+			continue
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		items = append(items, item{name: name, description: entryPoint, command: "poetry run " + name, source: "poetry"})
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no scripts found in pyproject.toml")
+	}
+
+	return items, nil
+}
+
+// CommandArgs returns the poetry command line running name, without
+// looking for poetry
+func (p *PyProjectScriptSource) CommandArgs(name string, extraArgs []string) ([]string, error) {
+	// Prepare arguments for poetry run, extra args go to the script
+	args := []string{"poetry", "run", name}
+	args = append(args, extraArgs...)
+
+	return args, nil
+}
+
+func (p *PyProjectScriptSource) ResolveCommand(name string, extraArgs []string) (string, []string, error) {
+	// Find the path to poetry executable
+	poetryPath, err := exec.LookPath("poetry")
+	if err != nil {
+		return "", nil, fmt.Errorf("poetry not found: %w", err)
+	}
+
+	args, err := p.CommandArgs(name, extraArgs)
+	if err != nil {
+		return "", nil, err
+	}
+	return poetryPath, args, nil
+}
+
+func (p *PyProjectScriptSource) RunScript(name string, extraArgs, env []string) error {
+	path, args, err := p.ResolveCommand(name, extraArgs)
+	if err != nil {
+		return err
+	}
+
+	// Replace the current process with the command
+	return runExec(path, args, env)
+}
+
+// poetryEntryPoint describes a poetry script, which is either a
+// "module:function" string or a table with a callable or reference
+func poetryEntryPoint(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case map[string]interface{}:
+		for _, key := range []string{"callable", "reference"} {
+			if s, ok := value[key].(string); ok {
+				return s
+			}
+		}
+	}
+	return "poetry script"
+}
+
+// ToxScriptSource handles the environments of a tox.ini, run with tox -e
+type ToxScriptSource struct{}
+
+func (t *ToxScriptSource) Name() string {
+	return "tox.ini"
+}
+
+func (t *ToxScriptSource) GetScripts() ([]list.Item, error) {
+	data, err := os.ReadFile("tox.ini")
+	if err != nil {
+		return nil, fmt.Errorf("error reading tox.ini: %w", err)
+	}
+
+	envs := parseToxEnvs(data)
+	if len(envs) == 0 {
+		return nil, fmt.Errorf("no environments found in tox.ini")
+	}
+
+	items := []list.Item{}
+	for _, env := range envs {
+		items = append(items, item{name: env, description: "tox environment", command: "tox -e " + env, source: "tox"})
+	}
+
+	return items, nil
+}
+
+// CommandArgs returns the tox command line running name, without
+// looking for tox
+func (t *ToxScriptSource) CommandArgs(name string, extraArgs []string) ([]string, error) {
+	// Prepare arguments for tox, extra args become {posargs} after "--"
+	args := []string{"tox", "-e", name}
+	if len(extraArgs) > 0 {
+		args = append(args, "--")
+		args = append(args, extraArgs...)
+	}
+
+	return args, nil
+}
+
+func (t *ToxScriptSource) ResolveCommand(name string, extraArgs []string) (string, []string, error) {
+	// Find the path to tox executable
+	toxPath, err := exec.LookPath("tox")
+	if err != nil {
+		return "", nil, fmt.Errorf("tox not found: %w", err)
+	}
+
+	args, err := t.CommandArgs(name, extraArgs)
+	if err != nil {
+		return "", nil, err
+	}
+	return toxPath, args, nil
+}
+
+func (t *ToxScriptSource) RunScript(name string, extraArgs, env []string) error {
+	path, args, err := t.ResolveCommand(name, extraArgs)
+	if err != nil {
+		return err
+	}
+
+	// Replace the current process with the command
+	return runExec(path, args, env)
+}
+
+// parseToxEnvs returns the environments of a tox.ini: those in the envlist
+// of the [tox] section, with generative names like py{311,312} expanded,
+// followed by any other [testenv:<name>] sections
+func parseToxEnvs(data []byte) []string {
+	envs := []string{}
+	seen := make(map[string]bool)
+	add := func(env string) {
+		if env != "" && !seen[env] {
+			seen[env] = true
+			envs = append(envs, env)
+		}
+	}
+
+	var envlist, sections []string
+	section := ""
+	inEnvlist := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+			continue
+		}
+
+		// Indented lines continue the previous value
+		if inEnvlist && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			envlist = append(envlist, trimmed)
+			continue
+		}
+		inEnvlist = false
+
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			if name, ok := strings.CutPrefix(section, "testenv:"); ok {
+				sections = append(sections, strings.TrimSpace(name))
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(trimmed, "=")
+		if ok && section == "tox" && strings.TrimSpace(key) == "envlist" {
+			envlist = append(envlist, strings.TrimSpace(value))
+			inEnvlist = true
+		}
+	}
+
+	for _, entry := range envlist {
+		for _, env := range splitToxEnvlist(entry) {
+			for _, expanded := range expandBraces(env) {
+				add(expanded)
+			}
+		}
+	}
+	for _, name := range sections {
+		add(name)
+	}
+	return envs
+}
+
+// splitToxEnvlist splits an envlist line on commas outside braces
+func splitToxEnvlist(line string) []string {
+	envs := []string{}
+	depth, start := 0, 0
+	for i, r := range line {
+		switch r {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				envs = append(envs, strings.TrimSpace(line[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(envs, strings.TrimSpace(line[start:]))
+}
+
+// expandBraces expands tox's generative names, so py{311,312}-lint becomes
+// py311-lint and py312-lint
+func expandBraces(name string) []string {
+	open := strings.Index(name, "{")
+	end := strings.Index(name, "}")
+	if open < 0 || end < open {
+		return []string{name}
+	}
+
+	expanded := []string{}
+	for _, choice := range strings.Split(name[open+1:end], ",") {
+		expanded = append(expanded, expandBraces(name[:open]+strings.TrimSpace(choice)+name[end+1:])...)
+	}
+	return expanded
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// rakefileNames are the file names rake looks for, in order
+var rakefileNames = []string{"Rakefile", "rakefile", "Rakefile.rb", "rakefile.rb"}
+
+// RakeScriptSource handles tasks from a Rakefile
+type RakeScriptSource struct {
+	Timeout time.Duration // limit for rake -T, 0 for none
+}
+
+func (r *RakeScriptSource) Name() string {
+	return findRakefile()
+}
+
+func (r *RakeScriptSource) GetScripts() ([]list.Item, error) {
+	// Check if a Rakefile exists
+	if findRakefile() == "" {
+		return nil, fmt.Errorf("Rakefile not found")
+	}
+
+	// Run rake -T to get the documented tasks with their descriptions
+	output, err := listOutput(r.Timeout, "rake", "-T")
+	if err != nil {
+		return nil, fmt.Errorf("error running rake -T: %w", err)
+	}
+
+	items := parseRakeTasks(string(output))
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no documented tasks found in Rakefile")
+	}
+
+	return items, nil
+}
+
+// CommandArgs returns the rake command line running name, without
+// looking for rake
+func (r *RakeScriptSource) CommandArgs(name string, extraArgs []string) ([]string, error) {
+	// Prepare arguments for rake, extra args are appended directly so
+	// they can set environment variables like VERSION=1.2
+	args := []string{"rake", name}
+	args = append(args, extraArgs...)
+
+	return args, nil
+}
+
+func (r *RakeScriptSource) ResolveCommand(name string, extraArgs []string) (string, []string, error) {
+	// Find the path to rake executable
+	rakePath, err := exec.LookPath("rake")
+	if err != nil {
+		return "", nil, fmt.Errorf("rake not found: %w", err)
+	}
+
+	args, err := r.CommandArgs(name, extraArgs)
+	if err != nil {
+		return "", nil, err
+	}
+	return rakePath, args, nil
+}
+
+func (r *RakeScriptSource) RunScript(name string, extraArgs, env []string) error {
+	path, args, err := r.ResolveCommand(name, extraArgs)
+	if err != nil {
+		return err
+	}
+
+	// Replace the current process with the command
+	return runExec(path, args, env)
+}
+
+// findRakefile returns the Rakefile in the current directory, if any
+func findRakefile() string {
+	for _, name := range rakefileNames {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// parseRakeTasks extracts tasks from rake -T output, which looks like:
+//
+//	rake build              # Build the gem
+//	rake db:migrate         # Migrate the database
+//	rake release[remote]    # Release to the remote
+//
+// Task arguments in brackets are shown in the command but not the name.
+func parseRakeTasks(output string) []list.Item {
+	items := []list.Item{}
+
+	for _, line := range strings.Split(output, "\n") {
+		signature, ok := strings.CutPrefix(line, "rake ")
+		if !ok {
+			continue
+		}
+
+		signature, doc, _ := strings.Cut(signature, "# ")
+		signature = strings.TrimSpace(signature)
+		name, _, _ := strings.Cut(signature, "[")
+		if name == "" {
+			continue
+		}
+
+		description := strings.TrimSpace(doc)
+		if description == "" {
+			description = "rake task"
+		}
+		items = append(items, item{name: name, description: description, command: "rake " + signature, source: "rake"})
+	}
+
+	return items
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+)
+
+// maxRecentDirs caps the directories --recent-dirs offers
+const maxRecentDirs = 15
+
+// recentDirs returns the directories of the history, most recent first and
+// each once, leaving out directories that no longer exist
+func recentDirs(history []historyEntry, limit int) []string {
+	seen := make(map[string]bool)
+	dirs := []string{}
+	for _, entry := range history {
+		if len(dirs) == limit {
+			break
+		}
+		if seen[entry.Dir] {
+			continue
+		}
+		seen[entry.Dir] = true
+		if info, err := os.Stat(entry.Dir); err != nil || !info.IsDir() {
+			continue
+		}
+		dirs = append(dirs, entry.Dir)
+	}
+	return dirs
+}
+
+// displayDir shortens a directory in the home directory to ~/...
+func displayDir(dir string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return dir
+	}
+	if dir == home {
+		return "~"
+	}
+	if rel, err := filepath.Rel(home, dir); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.Join("~", rel)
+	}
+	return dir
+}
+
+// pickRecentDir asks which of the directories rx recently ran scripts in
+// to use, for --recent-dirs. It returns "" when the picker is cancelled.
+func pickRecentDir() (string, error) {
+	history, err := loadHistory()
+	if err != nil {
+		return "", err
+	}
+	dirs := recentDirs(history, maxRecentDirs)
+	if len(dirs) == 0 {
+		return "", fmt.Errorf("no recent directories, run a script with rx first")
+	}
+
+	options := make([]huh.Option[string], 0, len(dirs))
+	for _, dir := range dirs {
+		options = append(options, huh.NewOption(displayDir(dir), dir))
+	}
+	var dir string
+	field := huh.NewSelect[string]().
+		Title("Recent directories").
+		Options(options...).
+		Value(&dir)
+	if err := huh.NewForm(huh.NewGroup(field)).WithShowHelp(false).Run(); err != nil {
+		if errors.Is(err, huh.ErrUserAborted) {
+			return "", nil
+		}
+		return "", err
+	}
+	return dir, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// scriptResult describes a script chosen in the picker, printed by
+// --print-result for editor plugins that run the command themselves
+type scriptResult struct {
+	Name    string   `json:"name"`
+	Source  string   `json:"source"`
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	Env     []string `json:"env,omitempty"`
+	Cwd     string   `json:"cwd"`
+}
+
+// sessionResult is the JSON object --print-result writes. One chosen
+// script is written as a scriptResult, a queue as Scripts, and quitting
+// without choosing as {"cancelled": true}.
+type sessionResult struct {
+	Cancelled bool           `json:"cancelled,omitempty"`
+	Scripts   []scriptResult `json:"scripts,omitempty"`
+	Parallel  bool           `json:"parallel,omitempty"`
+}
+
+// writeResult writes the scripts chosen in the picker to w as JSON instead
+// of running them. env holds the variables entered in the prompt, .env
+// files are left to whatever runs the command.
+func writeResult(w io.Writer, sources map[string]ScriptSource, items []item, extraArgs, env []string, cwd string, parallel bool) error {
+	var result any = sessionResult{Cancelled: true}
+
+	scripts := make([]scriptResult, 0, len(items))
+	for _, i := range items {
+		_, args, err := sources[i.source].ResolveCommand(i.name, extraArgs)
+		if err != nil {
+			return err
+		}
+		scripts = append(scripts, scriptResult{
+			Name:    i.name,
+			Source:  i.source,
+			Command: joinArgs(args),
+			Args:    args,
+			Env:     env,
+			Cwd:     cwd,
+		})
+	}
+	switch {
+	case len(scripts) == 1:
+		result = scripts[0]
+	case len(scripts) > 1:
+		result = sessionResult{Scripts: scripts, Parallel: parallel}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// hasScriptSource reports whether the current directory has the file of a
+// script source, or of opts.Source when it isn't empty
+func hasScriptSource(opts sourceOptions) bool {
+	for _, detector := range sourceDetectors {
+		if (opts.Source == "" || detector.tag == opts.Source) && detector.find(opts) {
+			return true
+		}
+	}
+	return false
+}
+
+// findProjectRoot makes the nearest directory with a script source the
+// working directory, looking in the current directory and then its
+// parents. The search stops at a directory containing .git, the top of a
+// repository, or at the filesystem root. It returns the directory it moved
+// to, or "" when it stayed in the current directory.
+func findProjectRoot(opts sourceOptions) (string, error) {
+	start, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for dir := start; ; {
+		if dir != start {
+			if err := os.Chdir(dir); err != nil {
+				return "", err
+			}
+		}
+		if hasScriptSource(opts) {
+			if dir == start {
+				return "", nil
+			}
+			return dir, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil || parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	// Nothing found, stay put so the usual errors mention this directory
+	return "", os.Chdir(start)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// runLogEntry is a line of the run log, one JSON object per run
+type runLogEntry struct {
+	Time    time.Time `json:"time"`
+	Dir     string    `json:"dir"`
+	Source  string    `json:"source"`
+	Name    string    `json:"name"`
+	Command string    `json:"command"`
+	Args    []string  `json:"args"`
+}
+
+// stateDir returns the directory rx keeps logs in, ~/.local/state/rx unless
+// XDG_STATE_HOME says otherwise
+func stateDir() (string, error) {
+	if xdg := os.Getenv("XDG_STATE_HOME"); xdg != "" {
+		return filepath.Join(xdg, "rx"), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".local", "state", "rx"), nil
+}
+
+// logRuns appends an entry for each of items to run.log in the state
+// directory, before they run since exec doesn't return
+func logRuns(dir string, sources map[string]ScriptSource, items []item, extraArgs []string) error {
+	stateDir, err := stateDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", stateDir, err)
+	}
+	path := filepath.Join(stateDir, "run.log")
+
+	var lines []byte
+	for _, i := range items {
+		_, args, err := sources[i.source].ResolveCommand(i.name, extraArgs)
+		if err != nil {
+			return err
+		}
+		line, err := json.Marshal(runLogEntry{
+			Time:    time.Now(),
+			Dir:     dir,
+			Source:  i.source,
+			Name:    i.name,
+			Command: joinArgs(args),
+			Args:    args,
+		})
+		if err != nil {
+			return err
+		}
+		lines = append(append(lines, line...), '\n')
+	}
+
+	// One write per run keeps lines whole when several rx append at once
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	if _, err := f.Write(lines); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/bubbles/list"
+	"gopkg.in/yaml.v3"
+)
+
+// rxFileNames are the names of rx's own task file, in order
+var rxFileNames = []string{".rx.yaml", ".rx.yml", ".rx.toml"}
+
+// RxFileScriptSource handles tasks from an .rx.yaml or .rx.toml file, shell
+// commands run with $SHELL -c:
+//
+//	default: build
+//	tasks:
+//	  build: go build ./...
+//	  test:
+//	    run: go test ./...
+//	    description: Run the tests
+type RxFileScriptSource struct {
+	// Default is the task rx run runs without a name, read by GetScripts
+	Default string
+
+	commands map[string]string
+	lines    map[string]int
+}
+
+// rxTask is a task of an rx file
+type rxTask struct {
+	name        string
+	run         string
+	description string
+	line        int // 0 when the line isn't known
+}
+
+// rxFile is the content of an rx file
+type rxFile struct {
+	Default string
+	Tasks   []rxTask
+}
+
+// findRxFile returns the rx file in the current directory, if any
+func findRxFile() string {
+	for _, name := range rxFileNames {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+func (r *RxFileScriptSource) Name() string {
+	return findRxFile()
+}
+
+func (r *RxFileScriptSource) GetScripts() ([]list.Item, error) {
+	path := findRxFile()
+	if path == "" {
+		return nil, fmt.Errorf("rx file not found")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	file, err := parseRxFile(path, data)
+	if err != nil {
+		return nil, &sourceFileError{File: path, Err: err}
+	}
+	if len(file.Tasks) == 0 {
+		return nil, fmt.Errorf("no tasks found in %s", path)
+	}
+
+	r.Default = file.Default
+	r.commands = make(map[string]string, len(file.Tasks))
+	r.lines = make(map[string]int, len(file.Tasks))
+	items := []list.Item{}
+	for _, task := range file.Tasks {
+		r.commands[task.name] = task.run
+		r.lines[task.name] = task.line
+		description := task.description
+		if description == "" {
+			description = task.run
+		}
+		items = append(items, item{name: task.name, description: description, command: task.run, source: "rx"})
+	}
+	if r.Default != "" {
+		if _, ok := r.commands[r.Default]; !ok {
+			return nil, &sourceFileError{File: path, Err: fmt.Errorf("default %q is not a task", r.Default)}
+		}
+	}
+
+	return items, nil
+}
+
+// parseRxFile reads the tasks of an rx file in declaration order, as YAML
+// or TOML by the extension of path. A task is a command or a table with
+// run and an optional description.
+func parseRxFile(path string, data []byte) (rxFile, error) {
+	if filepath.Ext(path) == ".toml" {
+		return parseRxTOML(data)
+	}
+	return parseRxYAML(data)
+}
+
+func parseRxYAML(data []byte) (rxFile, error) {
+	var raw struct {
+		Default string    `yaml:"default"`
+		Tasks   yaml.Node `yaml:"tasks"`
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return rxFile{}, err
+	}
+
+	file := rxFile{Default: raw.Default}
+	if raw.Tasks.Kind != yaml.MappingNode {
+		return file, nil
+	}
+
+	// Mapping nodes alternate key and value
+	for i := 0; i+1 < len(raw.Tasks.Content); i += 2 {
+		key, value := raw.Tasks.Content[i], raw.Tasks.Content[i+1]
+		task := rxTask{name: key.Value, line: key.Line}
+		switch value.Kind {
+		case yaml.ScalarNode:
+			task.run = value.Value
+		case yaml.MappingNode:
+			var fields struct {
+				Run         string `yaml:"run"`
+				Description string `yaml:"description"`
+			}
+			if err := value.Decode(&fields); err != nil {
+				return rxFile{}, fmt.Errorf("task %q: %w", task.name, err)
+			}
+			task.run, task.description = fields.Run, fields.Description
+		}
+		if task.run == "" {
+			return rxFile{}, fmt.Errorf("task %q has no command", task.name)
+		}
+		file.Tasks = append(file.Tasks, task)
+	}
+	return file, nil
+}
+
+func parseRxTOML(data []byte) (rxFile, error) {
+	var raw struct {
+		Default string                 `toml:"default"`
+		Tasks   map[string]interface{} `toml:"tasks"`
+	}
+	meta, err := toml.Decode(string(data), &raw)
+	if err != nil {
+		return rxFile{}, err
+	}
+
+	// Keep the order the tasks are declared in
+	file := rxFile{Default: raw.Default}
+	for _, key := range meta.Keys() {
+		if len(key) != 2 || key[0] != "tasks" {
+			continue
+		}
+		task := rxTask{name: key[1]}
+		switch value := raw.Tasks[task.name].(type) {
+		case string:
+			task.run = value
+		case map[string]interface{}:
+			task.run, _ = value["run"].(string)
+			task.description, _ = value["description"].(string)
+		}
+		if task.run == "" {
+			return rxFile{}, fmt.Errorf("task %q has no command", task.name)
+		}
+		file.Tasks = append(file.Tasks, task)
+	}
+	return file, nil
+}
+
+func (r *RxFileScriptSource) ResolveCommand(name string, extraArgs []string) (string, []string, error) {
+	command, ok := r.commands[name]
+	if !ok {
+		return "", nil, fmt.Errorf("no task named %q", name)
+	}
+	shell, err := loginShell()
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Extra args are quoted onto the end of the command
+	if len(extraArgs) > 0 {
+		command += " " + joinArgs(extraArgs)
+	}
+
+	return shell, []string{shell, "-c", command}, nil
+}
+
+func (r *RxFileScriptSource) RunScript(name string, extraArgs, env []string) error {
+	path, args, err := r.ResolveCommand(name, extraArgs)
+	if err != nil {
+		return err
+	}
+
+	// Replace the current process with the command
+	return runExec(path, args, env)
+}
+
+// defaultScript returns the task of the rx file that rx run runs without
+// a name, or "" when there is none
+func defaultScript(sources map[string]ScriptSource) string {
+	source := sources["rx"]
+	if shell, ok := source.(*shellSource); ok {
+		source = shell.ScriptSource
+	}
+	if rx, ok := source.(*RxFileScriptSource); ok {
+		return rx.Default
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+)
+
+// runSequence runs scripts one after another as child processes, since
+// exec can't come back to run the next one. It stops at the first script
+// that fails and returns its exit code, or 0 when they all succeed. env
+// overrides variables of rx's environment.
+func runSequence(sources map[string]ScriptSource, items []item, extraArgs, env []string) int {
+	// ctrl+c reaches the running script, which decides whether it
+	// stops. rx stays alive to report it and skip the rest. SIGTERM only
+	// reaches rx, which passes it on and stops after the script.
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, quitSignals...)
+	defer signal.Stop(interrupt)
+
+	for n, i := range items {
+		progress := previewStyle.Render(fmt.Sprintf("[%d/%d] ", n+1, len(items)))
+		path, args, err := sources[i.source].ResolveCommand(i.name, extraArgs)
+		if err != nil {
+			fmt.Println(progress + errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+			return 1
+		}
+		fmt.Println(progress + successStyle.Render("Running: "+joinArgs(args)))
+
+		cmd := exec.Command(path, args[1:]...)
+		cmd.Args = args
+		cmd.Env = mergeEnv(os.Environ(), env)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Start(); err != nil {
+			fmt.Println(errorStyle.Render(fmt.Sprintf("Error executing script: %v", err)))
+			return 1
+		}
+		exited := make(chan error, 1)
+		go func() {
+			exited <- cmd.Wait()
+		}()
+
+		var terminated os.Signal
+		for waiting := true; waiting; {
+			select {
+			case err = <-exited:
+				waiting = false
+			case sig := <-interrupt:
+				if sig != os.Interrupt {
+					cmd.Process.Signal(sig)
+					terminated = sig
+				}
+			}
+		}
+
+		var exitErr *exec.ExitError
+		if err != nil && !errors.As(err, &exitErr) {
+			fmt.Println(errorStyle.Render(fmt.Sprintf("Error executing script: %v", err)))
+			return 1
+		}
+		if terminated != nil {
+			status := fmt.Sprintf("Stopped %s (%v)", i.name, terminated)
+			if rest := items[n+1:]; len(rest) > 0 {
+				status += ", skipping " + itemNames(rest)
+			}
+			fmt.Println(errorStyle.Render(status))
+			return signalExitCode(terminated)
+		}
+		if code := exitCode(cmd.ProcessState); code != 0 {
+			status := fmt.Sprintf("%s failed with exit code %d", i.name, code)
+			if rest := items[n+1:]; len(rest) > 0 {
+				status += ", skipping " + itemNames(rest)
+			}
+			fmt.Println(errorStyle.Render(status))
+			return code
+		}
+	}
+
+	fmt.Println(successStyle.Render(fmt.Sprintf("Ran %s", itemNames(items))))
+	return 0
+}
+
+// itemNames lists the names of items for messages, like "clean, build"
+func itemNames(items []item) string {
+	names := make([]string, len(items))
+	for n, i := range items {
+		names[n] = i.name
+	}
+	return strings.Join(names, ", ")
+}
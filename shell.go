@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// shellSource runs the scripts of another source through the user's login
+// shell, as $SHELL -lc '<command>', so they see the PATH and version
+// managers like nvm that the shell's profile sets up
+type shellSource struct {
+	ScriptSource
+
+	// Setup runs in the shell before each script, like nvm use
+	Setup string
+}
+
+// loginShell returns the path of $SHELL, or /bin/sh when it's unset
+func loginShell() (string, error) {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	path, err := exec.LookPath(shell)
+	if err != nil {
+		return "", fmt.Errorf("login shell %s not found: %w", shell, err)
+	}
+	return path, nil
+}
+
+// shellArgs wraps the command args in a login shell command line after
+// setup, quoting each argument for -c
+func shellArgs(shell, setup string, args []string) []string {
+	command := joinArgs(args)
+	if setup != "" {
+		command = setup + " && " + command
+	}
+	return []string{shell, "-lc", command}
+}
+
+// commandBuilder is implemented by sources that run a tool found on PATH,
+// returning the command line without looking for the tool
+type commandBuilder interface {
+	CommandArgs(name string, extraArgs []string) ([]string, error)
+}
+
+// commandArgs returns the command line of the wrapped source. The login
+// shell finds the tool, which may only be on the PATH its profile sets up.
+func (s *shellSource) commandArgs(name string, extraArgs []string) ([]string, error) {
+	if builder, ok := s.ScriptSource.(commandBuilder); ok {
+		return builder.CommandArgs(name, extraArgs)
+	}
+	_, args, err := s.ScriptSource.ResolveCommand(name, extraArgs)
+	return args, err
+}
+
+func (s *shellSource) ResolveCommand(name string, extraArgs []string) (string, []string, error) {
+	args, err := s.commandArgs(name, extraArgs)
+	if err != nil {
+		return "", nil, err
+	}
+	shell, err := loginShell()
+	if err != nil {
+		return "", nil, err
+	}
+	return shell, shellArgs(shell, s.Setup, args), nil
+}
+
+func (s *shellSource) RunScript(name string, extraArgs []string, env []string) error {
+	args, err := s.commandArgs(name, extraArgs)
+	if err != nil {
+		return err
+	}
+	shell, err := loginShell()
+	if err != nil {
+		return err
+	}
+	if npm, ok := s.ScriptSource.(*NPMScriptSource); ok {
+		npm.warnFallback(args)
+		if s.Setup == "" {
+			npm.checkNodeVersion(shell)
+		}
+	}
+	return runExec(shell, shellArgs(shell, s.Setup, args), env)
+}
+
+// Locate finds scripts in the wrapped source's file, when it can
+func (s *shellSource) Locate(name string) (string, int, error) {
+	locator, ok := s.ScriptSource.(scriptLocator)
+	if !ok {
+		return "", 0, fmt.Errorf("can't find where %s is defined", name)
+	}
+	return locator.Locate(name)
+}
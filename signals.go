@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// quitSignals are the signals that stop rx cleanly rather than killing it
+// with the terminal left in the alt screen
+var quitSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// signalMsg tells the model rx got one of quitSignals
+type signalMsg struct {
+	signal os.Signal
+}
+
+// signalExitCode is the status a shell reports for a process stopped by
+// sig, 128 plus the signal number
+func signalExitCode(sig os.Signal) int {
+	if number, ok := sig.(syscall.Signal); ok {
+		return 128 + int(number)
+	}
+	return 1
+}
+
+// forwardSignals sends quitSignals to p as a signalMsg, so the model quits
+// and Bubble Tea leaves the alt screen and restores the terminal before rx
+// exits. Call the returned func once p has quit.
+func forwardSignals(p *tea.Program) func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, quitSignals...)
+	go func() {
+		for sig := range signals {
+			p.Send(signalMsg{signal: sig})
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(signals)
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// scriptStat counts the runs of a script in a directory. Unlike the
+// history, stats are never trimmed.
+type scriptStat struct {
+	Source string    `json:"source"`
+	Name   string    `json:"name"`
+	Count  int       `json:"count"`
+	Last   time.Time `json:"last"`
+}
+
+// loadAllStats reads the stats of every directory, keyed by directory
+func loadAllStats() (map[string][]scriptStat, error) {
+	path, err := statePath("stats.json")
+	if err != nil {
+		return nil, err
+	}
+	all := make(map[string][]scriptStat)
+	if err := loadJSON(path, &all); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// loadStats returns the stats of the scripts run in dir, most run first
+// and the most recent of those run as often
+func loadStats(dir string) ([]scriptStat, error) {
+	all, err := loadAllStats()
+	if err != nil {
+		return nil, err
+	}
+	stats := all[dir]
+	sort.SliceStable(stats, func(a, b int) bool {
+		if stats[a].Count != stats[b].Count {
+			return stats[a].Count > stats[b].Count
+		}
+		return stats[a].Last.After(stats[b].Last)
+	})
+	return stats, nil
+}
+
+// recordStats counts a run of a script in dir, just before it starts
+func recordStats(dir, name, source string) error {
+	path, err := statePath("stats.json")
+	if err != nil {
+		return err
+	}
+	return updateJSON(path, func() error {
+		all, err := loadAllStats()
+		if err != nil {
+			// Start over rather than failing the run on a corrupt file
+			all = make(map[string][]scriptStat)
+		}
+
+		stats := all[dir]
+		found := false
+		for index := range stats {
+			if stats[index].Source == source && stats[index].Name == name {
+				stats[index].Count++
+				stats[index].Last = time.Now()
+				found = true
+			}
+		}
+		if !found {
+			stats = append(stats, scriptStat{Source: source, Name: name, Count: 1, Last: time.Now()})
+		}
+		all[dir] = stats
+		return saveJSON(path, all)
+	})
+}
+
+// sortByFrequency moves the scripts of stats to the top in their order,
+// most run first, leaving the rest in their original order
+func sortByFrequency(items []list.Item, stats []scriptStat) []list.Item {
+	rank := make(map[string]int, len(stats))
+	for index, stat := range stats {
+		rank[stat.Source+"\x00"+stat.Name] = index
+	}
+	if len(rank) == 0 {
+		return items
+	}
+
+	sorted := make([]list.Item, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(a, b int) bool {
+		rankA, okA := historyRank(rank, sorted[a])
+		rankB, okB := historyRank(rank, sorted[b])
+		if okA != okB {
+			return okA
+		}
+		return okA && rankA < rankB
+	})
+	return sorted
+}
+
+// handleStats prints the scripts run in dir by how often they ran, as a
+// table or as JSON
+func handleStats(w io.Writer, dir string, asJSON bool) error {
+	stats, err := loadStats(dir)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		if stats == nil {
+			stats = []scriptStat{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+
+	if len(stats) == 0 {
+		fmt.Fprintf(w, "No scripts run in %s yet\n", dir)
+		return nil
+	}
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "RUNS\tSCRIPT\tSOURCE\tLAST RUN")
+	for _, stat := range stats {
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\n", stat.Count, stat.Name, stat.Source, stat.Last.Local().Format("2006-01-02 15:04"))
+	}
+	return table.Flush()
+}
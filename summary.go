@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// runSummary describes what running items with args does, for
+// --confirm-all: each command and its source, the directory it runs in and
+// the variables rx adds to the environment
+func (m model) runSummary(items []item, args []string) string {
+	label := func(name string) string {
+		return helpStyle.Render(fmt.Sprintf("%-12s", name))
+	}
+
+	lines := []string{titleStyle.Copy().UnsetMargins().Render("About to run"), ""}
+	for _, i := range items {
+		command := i.name
+		if _, resolved, err := m.sources[i.source].ResolveCommand(i.name, args); err == nil {
+			command = joinArgs(resolved)
+		}
+		lines = append(lines, label("Command")+previewStyle.Render(command), label("Source")+i.source)
+	}
+	lines = append(lines, label("Directory")+m.dir)
+
+	// Variables from the prompt override the .env files
+	env := mergeEnv(m.dotenv, m.env)
+	if len(env) == 0 {
+		lines = append(lines, label("Environment")+"unchanged")
+	}
+	for n, variable := range env {
+		name := ""
+		if n == 0 {
+			name = "Environment"
+		}
+		lines = append(lines, label(name)+variable)
+	}
+	return strings.Join(lines, "\n")
+}
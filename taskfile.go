@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"gopkg.in/yaml.v3"
+)
+
+// taskfileNames are the file names go-task looks for, in order
+var taskfileNames = []string{
+	"Taskfile.yml", "taskfile.yml", "Taskfile.yaml", "taskfile.yaml",
+	"Taskfile.dist.yml", "taskfile.dist.yml", "Taskfile.dist.yaml", "taskfile.dist.yaml",
+}
+
+// TaskfileScriptSource handles tasks from a Taskfile (go-task)
+type TaskfileScriptSource struct{}
+
+func (t *TaskfileScriptSource) Name() string {
+	return findTaskfile()
+}
+
+func (t *TaskfileScriptSource) GetScripts() ([]list.Item, error) {
+	// Check if a Taskfile exists
+	path := findTaskfile()
+	if path == "" {
+		return nil, fmt.Errorf("Taskfile not found")
+	}
+
+	// Read Taskfile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	items, err := parseTaskfile(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no tasks found in %s", path)
+	}
+
+	return items, nil
+}
+
+// CommandArgs returns the task command line running name, without
+// looking for task
+func (t *TaskfileScriptSource) CommandArgs(name string, extraArgs []string) ([]string, error) {
+	// Prepare arguments for task, extra args become CLI_ARGS after "--"
+	args := []string{"task", name}
+	if len(extraArgs) > 0 {
+		args = append(args, "--")
+		args = append(args, extraArgs...)
+	}
+
+	return args, nil
+}
+
+func (t *TaskfileScriptSource) ResolveCommand(name string, extraArgs []string) (string, []string, error) {
+	// Find the path to task executable
+	taskPath, err := exec.LookPath("task")
+	if err != nil {
+		return "", nil, fmt.Errorf("task not found, install it from https://taskfile.dev: %w", err)
+	}
+
+	args, err := t.CommandArgs(name, extraArgs)
+	if err != nil {
+		return "", nil, err
+	}
+	return taskPath, args, nil
+}
+
+func (t *TaskfileScriptSource) RunScript(name string, extraArgs, env []string) error {
+	path, args, err := t.ResolveCommand(name, extraArgs)
+	if err != nil {
+		return err
+	}
+
+	// Replace the current process with the command
+	return runExec(path, args, env)
+}
+
+// findTaskfile returns the Taskfile in the current directory, if any
+func findTaskfile() string {
+	for _, name := range taskfileNames {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// parseTaskfile extracts tasks from Taskfile YAML in declaration order.
+// The includes and vars sections are ignored for listing purposes.
+func parseTaskfile(data []byte) ([]list.Item, error) {
+	var taskfile struct {
+		Tasks yaml.Node `yaml:"tasks"`
+	}
+	if err := yaml.Unmarshal(data, &taskfile); err != nil {
+		return nil, err
+	}
+
+	items := []list.Item{}
+	if taskfile.Tasks.Kind != yaml.MappingNode {
+		return items, nil
+	}
+
+	// Mapping nodes alternate key and value
+	for i := 0; i+1 < len(taskfile.Tasks.Content); i += 2 {
+		name := taskfile.Tasks.Content[i].Value
+		value := taskfile.Tasks.Content[i+1]
+
+		var task struct {
+			Desc     string      `yaml:"desc"`
+			Summary  string      `yaml:"summary"`
+			Cmds     []yaml.Node `yaml:"cmds"`
+			Cmd      string      `yaml:"cmd"`
+			Internal bool        `yaml:"internal"`
+		}
+
+		switch value.Kind {
+		case yaml.ScalarNode:
+			// Short syntax: task: command
+			task.Cmd = value.Value
+		case yaml.SequenceNode:
+			// Short syntax: task: [commands]
+			if err := value.Decode(&task.Cmds); err != nil {
+				return nil, fmt.Errorf("task %q: %w", name, err)
+			}
+		case yaml.MappingNode:
+			if err := value.Decode(&task); err != nil {
+				return nil, fmt.Errorf("task %q: %w", name, err)
+			}
+		}
+
+		// Internal tasks can't be run directly
+		if task.Internal {
+			continue
+		}
+
+		commands := []string{}
+		if task.Cmd != "" {
+			commands = append(commands, task.Cmd)
+		}
+		for _, cmd := range task.Cmds {
+			if command := taskCommand(&cmd); command != "" {
+				commands = append(commands, command)
+			}
+		}
+
+		description := task.Desc
+		if description == "" {
+			description = strings.TrimSpace(strings.Split(task.Summary, "\n")[0])
+		}
+		if description == "" {
+			description = "task"
+		}
+
+		items = append(items, item{name: name, description: description, command: strings.Join(commands, "\n"), source: "task"})
+	}
+
+	return items, nil
+}
+
+// taskCommand describes an entry of a task's cmds list, which is either a
+// command string or a mapping with a cmd or task key
+func taskCommand(node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value
+	}
+
+	var cmd struct {
+		Cmd  string `yaml:"cmd"`
+		Task string `yaml:"task"`
+	}
+	if err := node.Decode(&cmd); err != nil {
+		return ""
+	}
+	if cmd.Task != "" {
+		return "task " + cmd.Task
+	}
+	return cmd.Cmd
+}
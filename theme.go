@@ -0,0 +1,181 @@
+package main
+
+import (
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// theme holds the colors of rx's styles. Colors set in the config file are
+// applied on top of it.
+type theme struct {
+	Title         lipgloss.TerminalColor
+	Error         lipgloss.TerminalColor
+	Success       lipgloss.TerminalColor
+	Preview       lipgloss.TerminalColor
+	Help          lipgloss.TerminalColor
+	Match         lipgloss.TerminalColor
+	Source        lipgloss.TerminalColor
+	NormalTitle   lipgloss.TerminalColor
+	NormalDesc    lipgloss.TerminalColor
+	SelectedTitle lipgloss.TerminalColor
+	SelectedDesc  lipgloss.TerminalColor
+	// Parallel tell apart the output of scripts run with --parallel
+	Parallel []lipgloss.TerminalColor
+	// Underline marks filter matches, for themes without colors
+	Underline bool
+}
+
+// themeNames are the built-in themes, in the order they're listed
+var themeNames = []string{"dark", "light", "high-contrast", "monochrome"}
+
+var themes = map[string]theme{
+	// One Dark
+	"dark": {
+		Title:         lipgloss.Color("#61AFEF"),
+		Error:         lipgloss.Color("#E06C75"),
+		Success:       lipgloss.Color("#98C379"),
+		Preview:       lipgloss.Color("#ABB2BF"),
+		Help:          lipgloss.Color("#5C6370"),
+		Match:         lipgloss.Color("#E5C07B"),
+		Source:        lipgloss.Color("#C678DD"),
+		NormalTitle:   lipgloss.Color("#DDDDDD"),
+		NormalDesc:    lipgloss.Color("#777777"),
+		SelectedTitle: lipgloss.Color("#61AFEF"),
+		SelectedDesc:  lipgloss.Color("#98C379"),
+		Parallel: []lipgloss.TerminalColor{
+			lipgloss.Color("#61AFEF"), lipgloss.Color("#98C379"), lipgloss.Color("#E5C07B"),
+			lipgloss.Color("#C678DD"), lipgloss.Color("#56B6C2"), lipgloss.Color("#E06C75"),
+		},
+	},
+	// One Light
+	"light": {
+		Title:         lipgloss.Color("#4078F2"),
+		Error:         lipgloss.Color("#E45649"),
+		Success:       lipgloss.Color("#50A14F"),
+		Preview:       lipgloss.Color("#383A42"),
+		Help:          lipgloss.Color("#A0A1A7"),
+		Match:         lipgloss.Color("#C18401"),
+		Source:        lipgloss.Color("#A626A4"),
+		NormalTitle:   lipgloss.Color("#1A1A1A"),
+		NormalDesc:    lipgloss.Color("#696C77"),
+		SelectedTitle: lipgloss.Color("#4078F2"),
+		SelectedDesc:  lipgloss.Color("#50A14F"),
+		Parallel: []lipgloss.TerminalColor{
+			lipgloss.Color("#4078F2"), lipgloss.Color("#50A14F"), lipgloss.Color("#C18401"),
+			lipgloss.Color("#A626A4"), lipgloss.Color("#0184BC"), lipgloss.Color("#E45649"),
+		},
+	},
+	// The bright ANSI colors, which the terminal's palette keeps readable
+	"high-contrast": {
+		Title:         lipgloss.Color("14"),
+		Error:         lipgloss.Color("9"),
+		Success:       lipgloss.Color("10"),
+		Preview:       lipgloss.Color("15"),
+		Help:          lipgloss.Color("7"),
+		Match:         lipgloss.Color("11"),
+		Source:        lipgloss.Color("13"),
+		NormalTitle:   lipgloss.Color("15"),
+		NormalDesc:    lipgloss.Color("7"),
+		SelectedTitle: lipgloss.Color("14"),
+		SelectedDesc:  lipgloss.Color("10"),
+		Parallel: []lipgloss.TerminalColor{
+			lipgloss.Color("14"), lipgloss.Color("10"), lipgloss.Color("11"),
+			lipgloss.Color("13"), lipgloss.Color("12"), lipgloss.Color("9"),
+		},
+		Underline: true,
+	},
+	"monochrome": {
+		Title:         lipgloss.NoColor{},
+		Error:         lipgloss.NoColor{},
+		Success:       lipgloss.NoColor{},
+		Preview:       lipgloss.NoColor{},
+		Help:          lipgloss.NoColor{},
+		Match:         lipgloss.NoColor{},
+		Source:        lipgloss.NoColor{},
+		NormalTitle:   lipgloss.NoColor{},
+		NormalDesc:    lipgloss.NoColor{},
+		SelectedTitle: lipgloss.NoColor{},
+		SelectedDesc:  lipgloss.NoColor{},
+		Parallel:      []lipgloss.TerminalColor{lipgloss.NoColor{}},
+		Underline:     true,
+	},
+}
+
+// activeTheme is the theme the styles were last set from
+var activeTheme theme
+
+func init() {
+	applyTheme(themes["dark"])
+}
+
+// defaultTheme picks light or dark from the terminal's background. When
+// the background can't be queried, as when output isn't a terminal, it's
+// taken to be dark. Without colors the terminal isn't queried at all.
+func defaultTheme() string {
+	if lipgloss.ColorProfile() == termenv.Ascii || lipgloss.HasDarkBackground() {
+		return "dark"
+	}
+	return "light"
+}
+
+// colorDisabled reports whether output should be plain text: with
+// --no-color, when NO_COLOR is set, or when one of outputs isn't a
+// terminal, as when output is piped or rx runs in CI
+func colorDisabled(noColor bool, outputs ...*os.File) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return true
+	}
+	for _, output := range outputs {
+		if !isTerminal(output) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTerminal reports whether f is a terminal rather than a file or pipe
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// disableColor makes every style, including those of the list and forms,
+// render plain text
+func disableColor() {
+	lipgloss.SetColorProfile(termenv.Ascii)
+}
+
+// applyTheme sets the package styles from t. The list delegate is created
+// from the active theme by newItemDelegate.
+func applyTheme(t theme) {
+	activeTheme = t
+	titleStyle = lipgloss.NewStyle().
+		Foreground(t.Title).
+		Bold(true).
+		MarginLeft(2)
+	errorStyle = lipgloss.NewStyle().
+		Foreground(t.Error).
+		Bold(true)
+	successStyle = lipgloss.NewStyle().
+		Foreground(t.Success).
+		Bold(true)
+	previewStyle = lipgloss.NewStyle().
+		Foreground(t.Preview)
+	helpStyle = lipgloss.NewStyle().
+		Foreground(t.Help)
+	filterStyle = lipgloss.NewStyle().
+		Padding(0, 1).
+		Border(lipgloss.NormalBorder(), false, false, true, false).
+		BorderForeground(t.Title)
+	errorBannerStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.Error).
+		Padding(0, 1)
+	paneStyle = lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(t.Help).
+		PaddingLeft(1)
+	parallelColors = t.Parallel
+}
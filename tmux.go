@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// tmuxModes are the values of --tmux: run the script in a new pane split
+// from rx's, or in a new window
+var tmuxModes = []string{"split", "window"}
+
+// inTmux reports whether rx runs inside a tmux session
+func inTmux() bool {
+	return os.Getenv("TMUX") != ""
+}
+
+// runInTmux runs name in a new tmux pane or window in the current directory
+// and returns once tmux has started it, leaving the shell rx ran in free
+func runInTmux(mode string, source ScriptSource, name string, extraArgs, env []string) error {
+	_, args, err := source.ResolveCommand(name, extraArgs)
+	if err != nil {
+		return err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("tmux", tmuxArgs(mode, name, cwd, args, env)...)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("tmux: %w", err)
+	}
+	return nil
+}
+
+// tmuxArgs builds the tmux command line running args in cwd. tmux runs the
+// command with its default shell, so it's passed as one quoted string. The
+// pane stays open once the script exits so its output can be read.
+func tmuxArgs(mode, name, cwd string, args, env []string) []string {
+	tmuxArgs := []string{"split-window"}
+	if mode == "window" {
+		tmuxArgs = []string{"new-window", "-n", name}
+	}
+	tmuxArgs = append(tmuxArgs, "-c", cwd)
+	// The pane gets the environment of the tmux server, not rx's, so the
+	// variables rx adds are passed along
+	for _, variable := range env {
+		tmuxArgs = append(tmuxArgs, "-e", variable)
+	}
+	return append(tmuxArgs, joinArgs(args), ";", "set-option", "-p", "remain-on-exit", "on")
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long watch mode waits after the last change before
+// restarting the script, so saving several files restarts it once
+const watchDebounce = 200 * time.Millisecond
+
+// watchStopTimeout is how long a script gets to exit after SIGTERM before
+// it's killed
+const watchStopTimeout = 5 * time.Second
+
+// watchSkipDirs are never watched, besides hidden directories
+var watchSkipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"target":       true,
+	"dist":         true,
+	"build":        true,
+}
+
+// watchScript runs a script and restarts it whenever a file below the
+// current directory changes, until rx is interrupted. With globs, only
+// files matching one of them restart it. The script runs as a child
+// process without rx's stdin, so ctrl+c reaches rx, which stops it. env
+// overrides variables of rx's environment.
+func watchScript(source ScriptSource, name string, extraArgs, env, globs []string) error {
+	execPath, args, err := source.ResolveCommand(name, extraArgs)
+	if err != nil {
+		return err
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("could not watch files: %w", err)
+	}
+	defer watcher.Close()
+	if err := watchTree(watcher, root); err != nil {
+		return fmt.Errorf("could not watch files: %w", err)
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, quitSignals...)
+	defer signal.Stop(interrupt)
+
+	// exited is nil while the script isn't running
+	var cmd *exec.Cmd
+	var exited chan error
+	start := func() {
+		fmt.Println(successStyle.Render("Running: " + joinArgs(args)))
+		cmd = exec.Command(execPath, args[1:]...)
+		cmd.Args = args
+		cmd.Env = mergeEnv(os.Environ(), env)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		setProcessGroup(cmd)
+		if err := cmd.Start(); err != nil {
+			fmt.Println(errorStyle.Render(fmt.Sprintf("Error executing script: %v", err)))
+			return
+		}
+		exited = make(chan error, 1)
+		go func(cmd *exec.Cmd, exited chan error) {
+			exited <- cmd.Wait()
+		}(cmd, exited)
+	}
+	stop := func() {
+		if exited == nil {
+			return
+		}
+		stopProcess(cmd, false)
+		select {
+		case <-exited:
+		case <-time.After(watchStopTimeout):
+			stopProcess(cmd, true)
+			<-exited
+		}
+		exited = nil
+	}
+
+	start()
+	var debounce <-chan time.Time
+	changed := ""
+	for {
+		select {
+		case sig := <-interrupt:
+			stop()
+			fmt.Println(successStyle.Render(fmt.Sprintf("Stopped watching (%v)", sig)))
+			return nil
+
+		case err := <-exited:
+			exited = nil
+			var exitErr *exec.ExitError
+			if err != nil && !errors.As(err, &exitErr) {
+				fmt.Println(errorStyle.Render(fmt.Sprintf("Error executing script: %v", err)))
+			}
+			status := fmt.Sprintf("%s exited with code %d", name, exitCode(cmd.ProcessState))
+			style := successStyle
+			if !cmd.ProcessState.Success() {
+				style = errorStyle
+			}
+			fmt.Println(style.Render(status) + previewStyle.Render(", waiting for changes…"))
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			rel, err := filepath.Rel(root, event.Name)
+			if err != nil || isWatchSkipped(rel) {
+				continue
+			}
+			// Directories created later are watched too
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					watchTree(watcher, event.Name)
+					continue
+				}
+			}
+			if event.Op == fsnotify.Chmod || !watchMatches(globs, rel) {
+				continue
+			}
+			changed = rel
+			debounce = time.After(watchDebounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintln(os.Stderr, "rx: warning: "+err.Error())
+
+		case <-debounce:
+			debounce = nil
+			stop()
+			fmt.Println(previewStyle.Render(changed + " changed, restarting"))
+			start()
+		}
+	}
+}
+
+// watchTree adds dir and the directories below it to watcher, skipping
+// hidden directories and those in watchSkipDirs
+func watchTree(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if p != dir && (strings.HasPrefix(d.Name(), ".") || watchSkipDirs[d.Name()]) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(p)
+	})
+}
+
+// isWatchSkipped reports whether a path relative to the watched directory
+// is in a skipped directory, or a hidden or backup file that editors write
+// while saving
+func isWatchSkipped(rel string) bool {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for _, part := range parts[:len(parts)-1] {
+		if strings.HasPrefix(part, ".") || watchSkipDirs[part] {
+			return true
+		}
+	}
+	base := parts[len(parts)-1]
+	return strings.HasPrefix(base, ".") || strings.HasSuffix(base, "~") || watchSkipDirs[base]
+}
+
+// watchMatches reports whether a changed path relative to the watched
+// directory should restart the script. Globs with a / match the whole
+// path, others match the file name in any directory. Without globs every
+// change matches.
+func watchMatches(globs []string, rel string) bool {
+	if len(globs) == 0 {
+		return true
+	}
+	rel = filepath.ToSlash(rel)
+	for _, glob := range globs {
+		name := rel
+		if !strings.Contains(glob, "/") {
+			name = path.Base(rel)
+		}
+		if ok, _ := path.Match(glob, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// npmWorkspace is a package inside an npm, yarn or pnpm workspace
+type npmWorkspace struct {
+	Name        string
+	Dir         string
+	Scripts     scriptList
+	ScriptsInfo map[string]any
+	ScriptsDocs map[string]any
+}
+
+// workspaceScript identifies a script in a workspace package
+type workspaceScript struct {
+	Workspace string
+	Script    string
+	Dir       string // package directory, to find the script for editing
+}
+
+// workspacePatterns reads the package globs from the workspaces field of
+// package.json, which is either an array or yarn's {"packages": [...]}, or
+// from pnpm-workspace.yaml when present
+func workspacePatterns(raw json.RawMessage) ([]string, error) {
+	var patterns []string
+
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &patterns); err != nil {
+			var yarnWorkspaces struct {
+				Packages []string `json:"packages"`
+			}
+			if err := json.Unmarshal(raw, &yarnWorkspaces); err != nil {
+				return nil, fmt.Errorf("workspaces must be an array or an object with packages")
+			}
+			patterns = yarnWorkspaces.Packages
+		}
+	}
+
+	if data, err := os.ReadFile("pnpm-workspace.yaml"); err == nil {
+		var pnpmWorkspace struct {
+			Packages []string `yaml:"packages"`
+		}
+		if err := yaml.Unmarshal(data, &pnpmWorkspace); err != nil {
+			return nil, fmt.Errorf("error parsing pnpm-workspace.yaml: %w", err)
+		}
+		patterns = append(patterns, pnpmWorkspace.Packages...)
+	}
+
+	return patterns, nil
+}
+
+// findWorkspaces expands workspace globs into the packages they match.
+// Patterns starting with ! exclude packages, and a trailing /** matches
+// packages at any depth.
+func findWorkspaces(patterns []string) ([]npmWorkspace, error) {
+	dirs := make(map[string]bool)
+	excludes := []string{}
+
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "!") {
+			excludes = append(excludes, filepath.Clean(strings.TrimPrefix(pattern, "!")))
+			continue
+		}
+
+		var matches []string
+		if base, ok := strings.CutSuffix(filepath.Clean(pattern), string(filepath.Separator)+"**"); ok {
+			matches = walkPackageDirs(base)
+		} else {
+			globbed, err := filepath.Glob(filepath.Clean(pattern))
+			if err != nil {
+				return nil, fmt.Errorf("invalid workspace pattern %q: %w", pattern, err)
+			}
+			matches = globbed
+		}
+
+		for _, dir := range matches {
+			if _, err := os.Stat(filepath.Join(dir, "package.json")); err == nil {
+				dirs[dir] = true
+			}
+		}
+	}
+
+	workspaces := []npmWorkspace{}
+	for dir := range dirs {
+		if matchesAny(excludes, dir) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+		if err != nil {
+			continue
+		}
+		var packageJSON struct {
+			Name        string         `json:"name"`
+			Scripts     scriptList     `json:"scripts"`
+			ScriptsInfo map[string]any `json:"scripts-info"`
+			ScriptsDocs map[string]any `json:"scripts-docs"`
+		}
+		if err := json.Unmarshal(data, &packageJSON); err != nil {
+			return nil, packageJSONError(filepath.Join(dir, "package.json"), data, err)
+		}
+
+		name := packageJSON.Name
+		if name == "" {
+			name = filepath.ToSlash(dir)
+		}
+		workspaces = append(workspaces, npmWorkspace{
+			Name:        name,
+			Dir:         dir,
+			Scripts:     packageJSON.Scripts,
+			ScriptsInfo: packageJSON.ScriptsInfo,
+			ScriptsDocs: packageJSON.ScriptsDocs,
+		})
+	}
+
+	sort.Slice(workspaces, func(i, j int) bool {
+		return workspaces[i].Dir < workspaces[j].Dir
+	})
+
+	return workspaces, nil
+}
+
+// walkPackageDirs lists every directory below base, skipping node_modules
+func walkPackageDirs(base string) []string {
+	dirs := []string{}
+	filepath.WalkDir(base, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if d.Name() == "node_modules" || (strings.HasPrefix(d.Name(), ".") && path != base) {
+			return filepath.SkipDir
+		}
+		dirs = append(dirs, path)
+		return nil
+	})
+	return dirs
+}
+
+// matchesAny reports whether path matches one of the glob patterns
+func matchesAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// workspaceRunArgs builds the command line to run a script in a workspace
+// package with the given package manager
+func workspaceRunArgs(manager string, ref workspaceScript) []string {
+	switch manager {
+	case "yarn":
+		return []string{"yarn", "workspace", ref.Workspace, "run", ref.Script}
+	case "pnpm":
+		return []string{"pnpm", "--filter", ref.Workspace, "run", ref.Script}
+	default:
+		return []string{"npm", "run", ref.Script, "--workspace=" + ref.Workspace}
+	}
+}